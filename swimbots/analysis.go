@@ -269,7 +269,7 @@ func GetNumSegmentsMap(pond *Pond) map[int]int{
     numSegmentsMap := make(map[int]int)
 
     // set the keys of the map based on range of close valuessince we are working with floats
-    for n:=2; n<9; n++{
+    for n:=minNumSegments; n<=maxGenomeLength; n++{
         numSegmentsMap[n]=0
     }
 
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"math/rand"
+	"strconv"
+)
+
+// Behaviour returns the view range, the hunger threshold and the mate preference the bot acts with.
+// With behaviour genes they come from its own genes, otherwise every bot uses the global parameters of the config.
+func (bot *Swimbot) Behaviour(config *SimulationConfig) (float64, float64, MatePreference) {
+	if !config.behaviourGenes {
+		return config.viewRange, config.hungerThreshold, config.MatePreference()
+	}
+	return bot.botGene.viewRange, bot.botGene.hungerThreshold, matePreferences[bot.botGene.preference].preference
+}
+
+// RandomBehaviour draws the behaviour genes of an initial bot around the global parameters of the config:
+// the view range and the hunger threshold lie uniformly within behaviourSpread times the global value on either side,
+// and the bot has the global mate preference, or with probability behaviourSpread a random one.
+func RandomBehaviour(common *CommonGene, config *SimulationConfig, r *rand.Rand) {
+	spread := config.behaviourSpread
+	common.viewRange = Clamp(config.viewRange*(1+spread*(2*r.Float64()-1)), minViewRange, maxViewRange)
+	common.hungerThreshold = Clamp(config.hungerThreshold*(1+spread*(2*r.Float64()-1)), minHungerThreshold, maxHungerThreshold)
+	common.preference = MatePreferenceIndex(config.matingPreference)
+	if r.Float64() < spread {
+		common.preference = r.Intn(len(matePreferences))
+	}
+}
+
+// InheritBehaviour gives the child the behaviour genes of one of the two parents each, like the movement genes.
+// Only call it with behaviour genes, so a run without them draws the same numbers as before they existed.
+func InheritBehaviour(child *CommonGene, gene1, gene2 CommonGene, r *rand.Rand) {
+	num := r.Intn(2)
+	if num == 0 {
+		child.viewRange = gene1.viewRange
+	} else {
+		child.viewRange = gene2.viewRange
+	}
+
+	num = r.Intn(2)
+	if num == 0 {
+		child.hungerThreshold = gene1.hungerThreshold
+	} else {
+		child.hungerThreshold = gene2.hungerThreshold
+	}
+
+	num = r.Intn(2)
+	if num == 0 {
+		child.preference = gene1.preference
+	} else {
+		child.preference = gene2.preference
+	}
+}
+
+// BehaviourRecorder is an observer that records the behaviour genes of the living bots every interval generations:
+// the mean view range, the mean hunger threshold and how many bots follow each mate preference.
+// It shows which foraging and mating strategies evolve.
+type BehaviourRecorder struct {
+	interval int
+	rows     [][]string
+}
+
+// NewBehaviourRecorder returns a recorder that records the behaviour genes every interval generations
+func NewBehaviourRecorder(interval int) *BehaviourRecorder {
+	var recorder BehaviourRecorder
+	recorder.interval = interval
+	header := []string{"step", "bots", "meanViewRange", "meanHungerThreshold"}
+	recorder.rows = append(recorder.rows, append(header, MatePreferenceNames()...))
+	return &recorder
+}
+
+// OnStep records the behaviour genes of the pond if gen is a multiple of the interval
+func (recorder *BehaviourRecorder) OnStep(gen int, pond *Pond) {
+	if gen%recorder.interval != 0 {
+		return
+	}
+	numBots := 0
+	viewRange, hungerThreshold := 0.0, 0.0
+	preferences := make([]int, len(matePreferences))
+	for _, b := range pond.swimbots {
+		if b == nil {
+			continue
+		}
+		numBots++
+		viewRange += b.botGene.viewRange
+		hungerThreshold += b.botGene.hungerThreshold
+		preferences[b.botGene.preference]++
+	}
+	if numBots > 0 {
+		viewRange /= float64(numBots)
+		hungerThreshold /= float64(numBots)
+	}
+
+	row := []string{strconv.Itoa(gen), strconv.Itoa(numBots), strconv.FormatFloat(viewRange, 'f', 2, 64), strconv.FormatFloat(hungerThreshold, 'f', 2, 64)}
+	for _, n := range preferences {
+		row = append(row, strconv.Itoa(n))
+	}
+	recorder.rows = append(recorder.rows, row)
+}
+
+// WriteBehaviourCSV writes the recorded behaviour into filename+".csv", one row per recording
+func (recorder *BehaviourRecorder) WriteBehaviourCSV(filename string) {
+	WriteRowsToCSV(recorder.rows, filename)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"math"
+)
+
+// The boundary modes of a pond, they decide what happens to a bot that swims over the edge.
+// "reflect" bounces the bot back into the pond, "wrap" makes the pond a torus where the bot comes back
+// in at the opposite edge, and "absorb" kills the bot. A pond without a boundary mode reflects.
+const (
+	reflectBoundary = "reflect"
+	wrapBoundary    = "wrap"
+	absorbBoundary  = "absorb"
+)
+
+// Displacement returns the vector from one position to another.
+// In a wrapping pond it takes the shortest way, which might cross an edge.
+func (pond *Pond) Displacement(from, to OrderedPair) OrderedPair {
+	delta := OrderedPair{to.x - from.x, to.y - from.y}
+	if pond.boundary == wrapBoundary {
+		delta.x = wrapDelta(delta.x, pond.width)
+		delta.y = wrapDelta(delta.y, pond.height)
+	}
+	return delta
+}
+
+// Distance returns the distance between two positions of the pond, see Displacement
+func (pond *Pond) Distance(a, b OrderedPair) float64 {
+	delta := pond.Displacement(a, b)
+	return math.Sqrt(delta.x*delta.x + delta.y*delta.y)
+}
+
+// Midpoint returns the position halfway between a and b, on the shortest way from a to b
+func (pond *Pond) Midpoint(a, b OrderedPair) OrderedPair {
+	if pond.boundary != wrapBoundary {
+		return OrderedPair{(a.x + b.x) * 0.5, (a.y + b.y) * 0.5}
+	}
+	delta := pond.Displacement(a, b)
+	return pond.WrapPosition(OrderedPair{a.x + delta.x*0.5, a.y + delta.y*0.5})
+}
+
+// WrapPosition returns the position inside a wrapping pond that corresponds to p.
+// In the other modes the position is returned unchanged.
+func (pond *Pond) WrapPosition(p OrderedPair) OrderedPair {
+	if pond.boundary != wrapBoundary {
+		return p
+	}
+	return OrderedPair{wrapCoordinate(p.x, pond.width), wrapCoordinate(p.y, pond.height)}
+}
+
+// ApplyBoundary handles a bot that swam over the edge of the pond after it moved.
+// It returns false if the bot was absorbed by the edge and has to die.
+func (pond *Pond) ApplyBoundary(bot *Swimbot) bool {
+	switch pond.boundary {
+	case wrapBoundary:
+		bot.position = pond.WrapPosition(bot.position)
+	case absorbBoundary:
+		if bot.position.x < 0 || bot.position.x > pond.width || bot.position.y < 0 || bot.position.y > pond.height {
+			return false
+		}
+	default:
+		bot.position.x, bot.velocity.x = reflectCoordinate(bot.position.x, bot.velocity.x, pond.width)
+		bot.position.y, bot.velocity.y = reflectCoordinate(bot.position.y, bot.velocity.y, pond.height)
+	}
+	bot.UpdateSegmentPositions()
+	return true
+}
+
+// reflectCoordinate mirrors a coordinate that left [0, max] back inside and turns its velocity around
+func reflectCoordinate(x, v, max float64) (float64, float64) {
+	if x < 0 {
+		x, v = -x, -v
+	} else if x > max {
+		x, v = 2*max-x, -v
+	}
+	// a bot that is faster than the pond is wide still has to end up inside
+	return Clamp(x, 0, max), v
+}
+
+// wrapCoordinate returns x modulo max, in [0, max)
+func wrapCoordinate(x, max float64) float64 {
+	x = math.Mod(x, max)
+	if x < 0 {
+		x += max
+	}
+	// adding max to a tiny negative x can round up to max
+	if x >= max {
+		x = 0
+	}
+	return x
+}
+
+// wrapDelta returns the shortest difference between two coordinates of a torus with circumference max
+func wrapDelta(d, max float64) float64 {
+	d = math.Mod(d, max)
+	if d > max/2 {
+		d -= max
+	} else if d < -max/2 {
+		d += max
+	}
+	return d
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+)
+
+// The steering modes. With "rule" a bot turns toward its goal as far as it can and swims at full speed,
+// or keeps going straight without a goal. With "neural" its brain decides the turn and the speed, see Brain.
+const (
+	ruleSteering   = "rule"
+	neuralSteering = "neural"
+)
+
+// The layout of the neural network of a bot: the sensor readings of Sense, one hidden layer and the turn and the throttle
+const (
+	numBrainInputs  = 6
+	numBrainHidden  = 6
+	numBrainOutputs = 2
+	// every neuron has a bias and a weight for every neuron of the layer before
+	brainGenomeLength = (numBrainInputs+1)*numBrainHidden + (numBrainHidden+1)*numBrainOutputs
+
+	// mutations of the weights are clamped to this range like the other traits
+	minBrainWeight = -4.0
+	maxBrainWeight = 4.0
+)
+
+// Brain decides how a bot steers from what it senses. Steer takes the sensor readings of Sense and returns
+// the turn between -1 and 1, as a fraction of the largest turn the bot can make (positive turns counter-clockwise),
+// and the throttle between 0 and 1, as a fraction of its top speed.
+type Brain interface {
+	Steer(sensors []float64) (turn, throttle float64)
+}
+
+// NeuralBrain is a feed-forward neural network with one hidden layer of tanh neurons.
+// Its weights are the brain genes of the bot, so they are inherited and mutated like the rest of the genome.
+type NeuralBrain struct {
+	weights []float64 // for every neuron of the hidden and then the output layer, the bias followed by its weights
+}
+
+// Steer feeds the sensor readings through the network
+func (brain NeuralBrain) Steer(sensors []float64) (turn, throttle float64) {
+	hidden := brain.layer(sensors, 0, numBrainHidden)
+	outputs := brain.layer(hidden, (numBrainInputs+1)*numBrainHidden, numBrainOutputs)
+	return outputs[0], (outputs[1] + 1) / 2
+}
+
+// layer returns the activations of n neurons fed by inputs, whose weights start at offset
+func (brain NeuralBrain) layer(inputs []float64, offset, n int) []float64 {
+	activations := make([]float64, n)
+	k := offset
+	for i := range activations {
+		sum := brain.weights[k]
+		k++
+		for _, input := range inputs {
+			sum += brain.weights[k] * input
+			k++
+		}
+		activations[i] = math.Tanh(sum)
+	}
+	return activations
+}
+
+// Brain returns the brain the bot steers with, nil if it follows the fixed rule of UpdateVelocity
+func (bot *Swimbot) Brain(config *SimulationConfig) Brain {
+	if config.steering != neuralSteering {
+		return nil
+	}
+	return NeuralBrain{bot.brainGenes}
+}
+
+// Sense returns the sensor readings of the bot: the distance and the angle to the closest food bit it can eat,
+// the distance and the angle to the closest mate it can see, its energy and its age.
+// Distances are fractions of the view range, 1 if there is nothing in view, and angles are measured from
+// the swimming direction as fractions of pi. The energy is relative to the hunger threshold and the age to the maximum age.
+func (bot *Swimbot) Sense(pond *Pond, config *SimulationConfig) []float64 {
+	viewRange, hungerThreshold, _ := bot.Behaviour(config)
+	heading := math.Atan2(bot.velocity.y, bot.velocity.x)
+
+	foodDistance, foodAngle := viewRange, 0.0
+	for _, i := range pond.FoodNear(bot.position, viewRange) {
+		food := pond.foodBits[i]
+		if food == nil || !food.EdibleBy(bot) {
+			continue
+		}
+		delta := pond.Displacement(bot.position, food.position)
+		dist := math.Hypot(delta.x, delta.y)
+		if dist < foodDistance && pond.LineOfSight(bot.position, food.position) {
+			foodDistance, foodAngle = dist, relativeAngle(delta, heading)
+		}
+	}
+
+	mateDistance, mateAngle := viewRange, 0.0
+	for _, mate := range bot.SuitableMates(pond, viewRange) {
+		dist := bot.DistanceToSwimbot(mate, pond)
+		if dist < mateDistance {
+			mateDistance, mateAngle = dist, relativeAngle(pond.Displacement(bot.position, mate.position), heading)
+		}
+	}
+
+	return []float64{
+		foodDistance / viewRange, foodAngle / math.Pi,
+		mateDistance / viewRange, mateAngle / math.Pi,
+		bot.energy / math.Max(hungerThreshold, 1), bot.age / config.maximumAge,
+	}
+}
+
+// relativeAngle returns the angle between the heading and the direction of delta, between -pi and pi
+func relativeAngle(delta OrderedPair, heading float64) float64 {
+	return math.Remainder(math.Atan2(delta.y, delta.x)-heading, 2*math.Pi)
+}
+
+// SteerWithBrain lets the brain turn the bot by at most turning and set its speed up to speed.
+// The bot never stops completely, so it always has a direction to turn from.
+func (bot *Swimbot) SteerWithBrain(brain Brain, pond *Pond, speed, turning float64, config *SimulationConfig) {
+	turn, throttle := brain.Steer(bot.Sense(pond, config))
+	heading := math.Atan2(bot.velocity.y, bot.velocity.x) + Clamp(turn, -1, 1)*turning
+	newSpeed := math.Max(Clamp(throttle, 0, 1)*speed, minTranslationalMovement)
+	bot.velocity.x = newSpeed * math.Cos(heading)
+	bot.velocity.y = newSpeed * math.Sin(heading)
+	bot.mainSegment.angle = heading
+}
+
+// RandomBrainGenes draws the weights of a new neural network from a standard normal distribution
+func RandomBrainGenes(r *rand.Rand) []float64 {
+	weights := make([]float64, brainGenomeLength)
+	for i := range weights {
+		weights[i] = Clamp(r.NormFloat64(), minBrainWeight, maxBrainWeight)
+	}
+	return weights
+}
+
+// CrossBrainGenes returns the weights of a child: the weights up to a random crossover point come from the first
+// parent and the rest from the second. It returns nil, and doesn't use r, if a parent has no brain.
+func CrossBrainGenes(weights1, weights2 []float64, r *rand.Rand) []float64 {
+	if weights1 == nil || weights2 == nil {
+		return nil
+	}
+	crosspoint := r.Intn(len(weights1))
+	weights := make([]float64, 0, len(weights1))
+	weights = append(weights, weights1[:crosspoint]...)
+	return append(weights, weights2[crosspoint:]...)
+}
+
+// MutateBrainGenes mutates every weight separately in place
+func MutateBrainGenes(weights []float64, mutation TraitMutation, r *rand.Rand) {
+	for i := range weights {
+		weights[i] = mutation.Apply(weights[i], minBrainWeight, maxBrainWeight, r)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+)
+
+// SimulationConfig holds all the parameters of a genepool simulation.
+// Use NewSimulationConfig to get a config with the default values and then change the fields you need.
+type SimulationConfig struct {
+	numGens             int     // number of generations to simulate
+	time                float64 // time interval of each generation
+	numInitialBots      int     // number of swimbots in the initial pond
+	numFood             int     // number of food bits we add every time we add food
+	initialFood         int     // number of food bits in the initial pond
+	viewRange           float64 // how far a swimbot can see when it picks its goal
+	proximity           float64 // how close a swimbot has to get to its goal to eat or mate
+	foodEnergy          float64 // energy a swimbot gains when eating a food bit
+	hungerThreshold     float64 // energy below which a swimbot looks for food instead of a mate
+	maximumAge          float64 // age at which a swimbot dies
+	foodFrequency       int     // number of generations between two food drops
+	segmentMass         float64 // mass of each segment of a swimbot
+	energyLossFactor    float64 // how fast a swimbot loses its energy when it swims
+	massModel           string  // "segments" or "area", see Mass
+	basalMetabolism     float64 // energy per unit of mass a swimbot loses every generation, even when it doesn't move
+	turningCost         float64 // energy per unit of mass and radian a swimbot loses when it turns
+	reproductionCost    float64 // energy each parent loses when a child is born, on top of the half it gives the child
+	matingPreference    string  // name of the MatePreference a swimbot uses to pick its mate
+	seed                int64   // seed of the random streams of the run, see NewRandomStreams
+	numWorkers          int     // number of goroutines updating the bots, 1 updates them serially
+	compactionInterval  int     // number of generations between two compactions of the pond, 0 never compacts
+	mutation            MutationConfig
+	diploid             bool // if true, every bot carries two copies of its genome, see Express
+	dominance           DominanceConfig
+	numSpecies          int     // number of founders the initial bots descend from, 1 gives every initial bot a random genome
+	founderSpread       float64 // how far the initial bots of a species lie from their founder, as a fraction of the range of every trait
+	speciesThreshold    float64 // genetic distance above which two bots can't mate, 0 lets every bot mate
+	behaviourGenes      bool    // if true, every bot has its own view range, hunger threshold and mate preference, see Behaviour
+	behaviourSpread     float64 // with behaviour genes, how far the initial genes lie from the global parameters, see RandomBehaviour
+	mutualChoice        bool    // if true, the goal of a swimbot can reject it, see AcceptsSuitor
+	acceptFraction      float64 // with mutual choice, a suitor is accepted if at most this fraction of the alternatives are better
+	rejectionCost       float64 // energy a swimbot loses when it gets rejected
+	locomotion          string  // "genes" or "morphology", see Locomotion
+	steering            string  // "rule" or "neural", see Brain
+	thrustFactor        float64 // with morphology locomotion, how strongly the strokes of the segments push a swimbot
+	turnFactor          float64 // with morphology locomotion, how strongly the segments along the body steer a swimbot
+	boundary            string  // "reflect", "wrap" or "absorb", see ApplyBoundary
+	width, height       float64 // size of the pond
+	obstacles           []Obstacle
+	foodModel           FoodModel   // nil uses the uniform model built from initialFood, numFood and foodFrequency
+	foodTypes           []*FoodType // empty for plain food that every bot eats and that gives foodEnergy
+	predation           bool        // if true, bots with a high diet hunt other bots, see IsPredator
+	initialPredators    float64     // with predation, fraction of the initial bots that are predators
+	predationEfficiency float64     // fraction of the prey's energy a predator gains
+	attackTrait         string      // "size", "speed" or "segments", see AttackStrength
+	attackCost          float64     // energy a predator loses when its prey escapes
+}
+
+// NewSimulationConfig returns a config filled with the default parameters of the simulation
+func NewSimulationConfig() *SimulationConfig {
+	var config SimulationConfig
+
+	config.numGens = 1000
+	config.time = 1
+	config.numInitialBots = 200
+	config.numFood = 5
+	config.initialFood = 400
+	config.viewRange = 300
+	config.proximity = 10
+	config.foodEnergy = 50
+	config.hungerThreshold = 50
+	config.maximumAge = 1000
+	config.foodFrequency = 5
+	config.segmentMass = 10.0
+	config.energyLossFactor = 0.0005
+	config.massModel = segmentsMass
+	config.basalMetabolism = 0
+	config.turningCost = 0
+	config.reproductionCost = 0
+	config.matingPreference = "random"
+	config.seed = 0
+	// the result doesn't depend on the number of workers, main asks how many cores to use
+	config.numWorkers = 1
+	config.compactionInterval = 50
+	config.behaviourGenes = false
+	config.behaviourSpread = 0.2
+	config.mutualChoice = false
+	config.acceptFraction = 0.5
+	config.rejectionCost = 5
+	config.locomotion = genesLocomotion
+	config.steering = ruleSteering
+	config.thrustFactor = 25
+	config.turnFactor = 2
+	config.boundary = reflectBoundary
+	config.width = 6000
+	config.height = 6000
+	config.predation = false
+	config.initialPredators = 0.1
+	config.predationEfficiency = 0.5
+	config.attackTrait = sizeAttack
+	config.attackCost = 5
+	config.numSpecies = 1
+	config.founderSpread = 0.05
+	config.speciesThreshold = 0
+	config.mutation = NewMutationConfig()
+	config.diploid = false
+	config.dominance = NewDominanceConfig()
+
+	return &config
+}
+
+// Validate checks that every parameter of the config can be used in a simulation.
+// It returns an error describing the first invalid parameter, or nil if the config is valid.
+func (config *SimulationConfig) Validate() error {
+	if config.numGens < 0 {
+		return fmt.Errorf("number of generations must be non-negative, got %d", config.numGens)
+	}
+	if config.time <= 0 {
+		return fmt.Errorf("time interval must be positive, got %v", config.time)
+	}
+	if config.numInitialBots < 0 {
+		return fmt.Errorf("initial number of bots must be non-negative, got %d", config.numInitialBots)
+	}
+	if config.numFood < 0 {
+		return fmt.Errorf("number of food bits must be non-negative, got %d", config.numFood)
+	}
+	if config.initialFood < 0 {
+		return fmt.Errorf("initial number of food bits must be non-negative, got %d", config.initialFood)
+	}
+	if config.viewRange <= 0 {
+		return fmt.Errorf("view range must be positive, got %v", config.viewRange)
+	}
+	if config.proximity < 0 {
+		return fmt.Errorf("proximity must be non-negative, got %v", config.proximity)
+	}
+	if config.foodEnergy < 0 {
+		return fmt.Errorf("food energy must be non-negative, got %v", config.foodEnergy)
+	}
+	if config.hungerThreshold < 0 {
+		return fmt.Errorf("hunger threshold must be non-negative, got %v", config.hungerThreshold)
+	}
+	if config.maximumAge <= 0 {
+		return fmt.Errorf("maximum age must be positive, got %v", config.maximumAge)
+	}
+	// food frequency is used as a modulus in AddFood
+	if config.foodFrequency <= 0 {
+		return fmt.Errorf("food frequency must be positive, got %d", config.foodFrequency)
+	}
+	if config.segmentMass <= 0 {
+		return fmt.Errorf("segment mass must be positive, got %v", config.segmentMass)
+	}
+	if config.energyLossFactor < 0 {
+		return fmt.Errorf("energy loss factor must be non-negative, got %v", config.energyLossFactor)
+	}
+	if config.massModel != segmentsMass && config.massModel != areaMass {
+		return fmt.Errorf("mass model must be %q or %q, got %q", segmentsMass, areaMass, config.massModel)
+	}
+	if config.basalMetabolism < 0 || config.turningCost < 0 || config.reproductionCost < 0 {
+		return fmt.Errorf("basal metabolism, turning cost and reproduction cost must be non-negative, got %v, %v and %v", config.basalMetabolism, config.turningCost, config.reproductionCost)
+	}
+	if _, err := LookupMatePreference(config.matingPreference); err != nil {
+		return err
+	}
+	if config.numWorkers < 1 {
+		return fmt.Errorf("number of workers must be at least 1, got %d", config.numWorkers)
+	}
+	if config.compactionInterval < 0 {
+		return fmt.Errorf("compaction interval must be non-negative, got %d", config.compactionInterval)
+	}
+	if config.behaviourSpread < 0 || config.behaviourSpread > 1 {
+		return fmt.Errorf("behaviour spread must be between 0 and 1, got %v", config.behaviourSpread)
+	}
+	if config.acceptFraction < 0 || config.acceptFraction > 1 {
+		return fmt.Errorf("accept fraction must be between 0 and 1, got %v", config.acceptFraction)
+	}
+	if config.rejectionCost < 0 {
+		return fmt.Errorf("rejection cost must be non-negative, got %v", config.rejectionCost)
+	}
+	if config.locomotion != genesLocomotion && config.locomotion != morphologyLocomotion {
+		return fmt.Errorf("locomotion must be %q or %q, got %q", genesLocomotion, morphologyLocomotion, config.locomotion)
+	}
+	if config.steering != ruleSteering && config.steering != neuralSteering {
+		return fmt.Errorf("steering must be %q or %q, got %q", ruleSteering, neuralSteering, config.steering)
+	}
+	if config.thrustFactor <= 0 || config.turnFactor <= 0 {
+		return fmt.Errorf("thrust and turn factor must be positive, got %v and %v", config.thrustFactor, config.turnFactor)
+	}
+	// the bots are spawned in the middle two thirds of the pond, which has to hold at least one whole position
+	if config.width < 3 || config.height < 3 {
+		return fmt.Errorf("width and height of the pond must be at least 3, got %v and %v", config.width, config.height)
+	}
+	if config.boundary != reflectBoundary && config.boundary != wrapBoundary && config.boundary != absorbBoundary {
+		return fmt.Errorf("boundary must be %q, %q or %q, got %q", reflectBoundary, wrapBoundary, absorbBoundary, config.boundary)
+	}
+	if config.initialPredators < 0 || config.initialPredators > 1 {
+		return fmt.Errorf("fraction of initial predators must be between 0 and 1, got %v", config.initialPredators)
+	}
+	if config.predationEfficiency < 0 || config.attackCost < 0 {
+		return fmt.Errorf("predation efficiency and attack cost must be non-negative, got %v and %v", config.predationEfficiency, config.attackCost)
+	}
+	if config.attackTrait != sizeAttack && config.attackTrait != speedAttack && config.attackTrait != segmentsAttack {
+		return fmt.Errorf("attack trait must be %q, %q or %q, got %q", sizeAttack, speedAttack, segmentsAttack, config.attackTrait)
+	}
+	if config.numSpecies < 1 {
+		return fmt.Errorf("number of species must be at least 1, got %d", config.numSpecies)
+	}
+	if config.founderSpread < 0 || config.speciesThreshold < 0 {
+		return fmt.Errorf("founder spread and species threshold must be non-negative, got %v and %v", config.founderSpread, config.speciesThreshold)
+	}
+	if err := config.mutation.Validate(); err != nil {
+		return err
+	}
+	if err := config.dominance.Validate(); err != nil {
+		return err
+	}
+	if err := config.FoodModel().Validate(); err != nil {
+		return err
+	}
+	for _, t := range config.foodTypes {
+		if err := t.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MatePreference returns the registered preference named by matingPreference.
+// It panics if there is none, call Validate first.
+func (config *SimulationConfig) MatePreference() MatePreference {
+	preference, err := LookupMatePreference(config.matingPreference)
+	if err != nil {
+		panic(err)
+	}
+	return preference
+}
+
+// FoodModel returns the food model of the run, the uniform model of the original simulation if none was set
+func (config *SimulationConfig) FoodModel() FoodModel {
+	if config.foodModel == nil {
+		return NewUniformFood(config.initialFood, config.numFood, config.foodFrequency)
+	}
+	return config.foodModel
+}
@@ -5,6 +5,19 @@ type Pond struct {
 	swimbots 	[]*Swimbot
 	foodBits 	[]*Food
 	width    	float64
+	height   	float64
+	boundary 	string // what happens at the edge of the pond, see ApplyBoundary
+	obstacles	[]Obstacle // never change during a run, so every pond of the run shares them
+	foodTypes	[]*FoodType // the same for every pond of the run, empty for plain food
+	speciesThreshold	float64 // bots further apart genetically can't mate, 0 lets every bot mate, see CompatibleWith
+	rng      	*RandomStreams
+	pedigree 	*Pedigree
+	// IDs of the next bot and food bit added to the pond, and the slot of every ID in the slices
+	nextBotID  	int
+	nextFoodID 	int
+	botSlots   	map[int]int
+	foodSlots  	map[int]int
+	grid     	*SpatialGrid // only set while the pond is searched by UpdatePond
 }
 
 type OrderedPair struct {
@@ -13,26 +26,36 @@ type OrderedPair struct {
 }
 
 type Swimbot struct {
+	id                               int // permanent ID, given when the bot is added to the pond
 	goal                             Goal
 	age                              float64
 	energy                           float64
 	position, velocity, acceleration OrderedPair
 	mass                             float64
-	family                           []int
+	family                           []int           // IDs of the bot itself, its parents and its children
+	rejections                       int             // number of times the bot was rejected by the mate it courted
+	rejectedBy                       map[int]float64 // ID of every bot that rejected the bot to the age it was rejected at, see RejectedBy
 	botGene                          CommonGene
 	segGenes                         []SegmentGene
+	brainGenes                       []float64   // weights of the neural network of the bot, nil without neural steering, see Brain
+	haplotypes                       []Haplotype // the two copies of the genome of a diploid bot, botGene and segGenes hold their phenotype
 	mainSegment                      *Segment
 }
 
 type Goal struct {
-	isBot bool
-	index int
+	isBot  bool
+	id     int  // ID of the bot or food bit, -1 if there is no goal
+	isPrey bool // the goal bot is hunted instead of courted
 }
 
 type CommonGene struct {
 	angularMovement       float64
 	translationalMovement float64
 	numSegments           int
+	diet                  float64 // 0 eats only food bits, from predatorDiet on the bot hunts other bots, see IsPredator
+	viewRange             float64 // the behaviour genes, only used with behaviourGenes, see Behaviour
+	hungerThreshold       float64
+	preference            int // index of the mate preference in MatePreferenceNames
 }
 
 type Segment struct {
@@ -47,7 +70,13 @@ type SegmentGene []float64
 // 0 red, 1 blue, 2 green float64
 // 3 angleToParent float64
 // 4 length, 5 width float64
+// 6 oscillationAmplitude, 7 oscillationPhase float64
+// 8 attachment float64, which of the segments before it the segment is attached to
+// A genome has between minNumSegments and maxGenomeLength segment genes. Only the first numSegments of them
+// are expressed, the others are silent until a mutation of numSegments or a deletion brings them into the body.
 
 type Food struct {
+	id       int
 	position OrderedPair
+	foodType *FoodType // nil for the plain food of a pond without food types
 }
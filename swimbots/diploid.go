@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// The dominance rules of a trait in a diploid genome, see Combine.
+// With "dominant" the higher allele shows as soon as one copy carries it, with "recessive" it only shows
+// if both copies carry it, and with "codominant" the bot expresses the average of its two alleles.
+const (
+	dominant   = "dominant"
+	recessive  = "recessive"
+	codominant = "codominant"
+)
+
+// Haplotype is one of the two copies of the genome a diploid bot carries
+type Haplotype struct {
+	botGene    CommonGene
+	segGenes   []SegmentGene
+	brainGenes []float64
+}
+
+// DominanceConfig holds the dominance rule of every trait of the genome
+type DominanceConfig struct {
+	color                 string // applied to the red, green and blue trait separately
+	angleToParent         string
+	length                string
+	width                 string
+	angularMovement       string
+	translationalMovement string
+	oscillationAmplitude  string
+	oscillationPhase      string
+	attachment            string
+	diet                  string
+	viewRange             string
+	hungerThreshold       string
+	numSegments           string
+}
+
+// NewDominanceConfig returns the default dominance rules.
+// The colour is dominant, so the classic Mendelian ratios show in the drawing, and every other trait is codominant.
+func NewDominanceConfig() DominanceConfig {
+	var dominance DominanceConfig
+	dominance.color = dominant
+	dominance.angleToParent = codominant
+	dominance.length = codominant
+	dominance.width = codominant
+	dominance.angularMovement = codominant
+	dominance.translationalMovement = codominant
+	dominance.oscillationAmplitude = codominant
+	dominance.oscillationPhase = codominant
+	dominance.attachment = codominant
+	dominance.diet = codominant
+	dominance.viewRange = codominant
+	dominance.hungerThreshold = codominant
+	dominance.numSegments = codominant
+	return dominance
+}
+
+// Validate checks that every trait has one of the three dominance rules
+func (dominance DominanceConfig) Validate() error {
+	traits := map[string]string{
+		"color":                 dominance.color,
+		"angleToParent":         dominance.angleToParent,
+		"length":                dominance.length,
+		"width":                 dominance.width,
+		"angularMovement":       dominance.angularMovement,
+		"translationalMovement": dominance.translationalMovement,
+		"oscillationAmplitude":  dominance.oscillationAmplitude,
+		"oscillationPhase":      dominance.oscillationPhase,
+		"attachment":            dominance.attachment,
+		"diet":                  dominance.diet,
+		"viewRange":             dominance.viewRange,
+		"hungerThreshold":       dominance.hungerThreshold,
+		"numSegments":           dominance.numSegments,
+	}
+	for name, rule := range traits {
+		if rule != dominant && rule != recessive && rule != codominant {
+			return fmt.Errorf("dominance of %s must be %q, %q or %q, got %q", name, dominant, recessive, codominant, rule)
+		}
+	}
+	return nil
+}
+
+// segmentRules returns the rule of every trait of a segment gene, in the order of the gene
+func (dominance DominanceConfig) segmentRules() []string {
+	return []string{
+		dominance.color, dominance.color, dominance.color,
+		dominance.angleToParent,
+		dominance.length,
+		dominance.width,
+		dominance.oscillationAmplitude,
+		dominance.oscillationPhase,
+		dominance.attachment,
+	}
+}
+
+// Combine returns the value a bot with the alleles a and b expresses under the dominance rule
+func Combine(rule string, a, b float64) float64 {
+	switch rule {
+	case dominant:
+		return math.Max(a, b)
+	case recessive:
+		return math.Min(a, b)
+	}
+	return (a + b) / 2
+}
+
+// Express returns the phenotype of a diploid genome, the genes that drive the movement and the drawing of the bot.
+// Every trait combines the alleles of the two copies by its dominance rule.
+// A segment gene that only the longer copy has is expressed as it is.
+func Express(haplotypes []Haplotype, dominance DominanceConfig) (CommonGene, []SegmentGene) {
+	first, second := haplotypes[0], haplotypes[1]
+
+	var common CommonGene
+	common.angularMovement = Combine(dominance.angularMovement, first.botGene.angularMovement, second.botGene.angularMovement)
+	common.translationalMovement = Combine(dominance.translationalMovement, first.botGene.translationalMovement, second.botGene.translationalMovement)
+	common.diet = Combine(dominance.diet, first.botGene.diet, second.botGene.diet)
+	common.viewRange = Combine(dominance.viewRange, first.botGene.viewRange, second.botGene.viewRange)
+	common.hungerThreshold = Combine(dominance.hungerThreshold, first.botGene.hungerThreshold, second.botGene.hungerThreshold)
+	// mate preferences have no order to combine them by, so the bot follows the one of its first copy, from its mother
+	common.preference = first.botGene.preference
+	// the average of an odd number of segments rounds down
+	common.numSegments = int(Combine(dominance.numSegments, float64(first.botGene.numSegments), float64(second.botGene.numSegments)))
+
+	longer, shorter := first.segGenes, second.segGenes
+	if len(shorter) > len(longer) {
+		longer, shorter = shorter, longer
+	}
+	rules := dominance.segmentRules()
+	segGenes := make([]SegmentGene, len(longer))
+	for i := range segGenes {
+		segGenes[i] = append(SegmentGene{}, longer[i]...)
+		if i >= len(shorter) {
+			continue
+		}
+		for k, rule := range rules {
+			segGenes[i][k] = Combine(rule, longer[i][k], shorter[i][k])
+		}
+	}
+	return common, segGenes
+}
+
+// Gamete returns the copy of the genome a diploid bot passes on to a child. Like meiosis, it shuffles the two copies
+// of the bot: every common trait comes from one of them and every segment gene is a crossover of both.
+// A haploid bot passes on its own genome.
+func (bot *Swimbot) Gamete(config *SimulationConfig, r *rand.Rand) Haplotype {
+	if bot.haplotypes == nil {
+		return Haplotype{bot.botGene, GenomeCopy(bot).segGenes, append([]float64(nil), bot.brainGenes...)}
+	}
+	// the first copy gives the start of every crossover, so draw which one it is to give both alleles the same chance
+	k := r.Intn(2)
+	var first, second Swimbot
+	first.botGene, first.segGenes = bot.haplotypes[k].botGene, bot.haplotypes[k].segGenes
+	second.botGene, second.segGenes = bot.haplotypes[1-k].botGene, bot.haplotypes[1-k].segGenes
+	segGenes, common := GenerateOffspringGenome(&first, &second, r)
+	// GenerateOffspringGenome blends the diets of two parents, but a gamete carries only one of the two alleles
+	common.diet = bot.haplotypes[r.Intn(2)].botGene.diet
+	if config.behaviourGenes {
+		InheritBehaviour(&common, bot.haplotypes[k].botGene, bot.haplotypes[1-k].botGene, r)
+	}
+	brainGenes := CrossBrainGenes(bot.haplotypes[k].brainGenes, bot.haplotypes[1-k].brainGenes, r)
+	return Haplotype{common, segGenes, brainGenes}
+}
+
+// ExpressBrain returns the weights of the neural network of a diploid bot, the average of the weights of its two copies.
+// A single weight means nothing on its own, so there is no dominance for the brain.
+func ExpressBrain(haplotypes []Haplotype) []float64 {
+	first, second := haplotypes[0].brainGenes, haplotypes[1].brainGenes
+	if first == nil || second == nil {
+		return nil
+	}
+	weights := make([]float64, len(first))
+	for i := range weights {
+		weights[i] = (first[i] + second[i]) / 2
+	}
+	return weights
+}
+
+// CopyHaplotypes returns a deep copy of the haplotypes, nil for a haploid bot
+func CopyHaplotypes(haplotypes []Haplotype) []Haplotype {
+	if haplotypes == nil {
+		return nil
+	}
+	copied := make([]Haplotype, len(haplotypes))
+	for k, h := range haplotypes {
+		copied[k].botGene = h.botGene
+		copied[k].brainGenes = append([]float64(nil), h.brainGenes...)
+		copied[k].segGenes = make([]SegmentGene, len(h.segGenes))
+		for i := range h.segGenes {
+			copied[k].segGenes[i] = append(SegmentGene{}, h.segGenes[i]...)
+		}
+	}
+	return copied
+}
@@ -3,13 +3,14 @@ package main
 import (
 	"canvas"
 	"image"
+	"math"
 
 )
 
 // AnimateSystem takes a slice of Universe objects along with a canvas width
 // parameter and a frequency parameter.
 // Every frequency steps, it generates a slice of images corresponding to drawing each Universe
-// on a canvasWidth x canvasWidth canvas.
+// on a canvas canvasWidth pixels wide, with the proportions of the pond.
 // A scaling factor is a final input that is used to scale the stars big enough to see them.
 func AnimateSystem(timePoints []*Pond, canvasWidth, frequency int, scalingFactor float64) []image.Image {
 	images := make([]image.Image, 0)
@@ -28,22 +29,63 @@ func AnimateSystem(timePoints []*Pond, canvasWidth, frequency int, scalingFactor
 	return images
 }
 
+// FrameRecorder is an observer that draws every frequency-th pond of a simulation as soon as it is produced,
+// so the ponds themselves don't need to be kept until the end of the run.
+type FrameRecorder struct {
+	canvasWidth   int
+	frequency     int
+	scalingFactor float64
+	images        []image.Image
+}
+
+// NewFrameRecorder takes the same drawing parameters as AnimateSystem and returns an empty recorder
+func NewFrameRecorder(canvasWidth, frequency int, scalingFactor float64) *FrameRecorder {
+	var f FrameRecorder
+	f.canvasWidth = canvasWidth
+	f.frequency = frequency
+	f.scalingFactor = scalingFactor
+	return &f
+}
+
+// OnStep draws the pond if gen is a multiple of the frequency
+func (f *FrameRecorder) OnStep(gen int, pond *Pond) {
+	if gen%f.frequency == 0 {
+		f.images = append(f.images, pond.DrawToCanvas(f.canvasWidth, f.scalingFactor))
+	}
+}
+
+// Images returns the frames drawn so far
+func (f *FrameRecorder) Images() []image.Image {
+	return f.images
+}
+
 // DrawToCanvas generates the image corresponding to a canvas after drawing a Universe
-// object's bodies on a square canvas that is canvasWidth pixels x canvasWidth pixels.
+// object's bodies on a canvas that is canvasWidth pixels wide and has the proportions of the pond.
 // A scaling factor is needed to make the stars big enough to see them.
 func (p *Pond) DrawToCanvas(canvasWidth int, scalingFactor float64) image.Image {
 	if p == nil {
 		panic("Can't Draw a nil pond.")
 	}
 
-	// set a new square canvas
-	c := canvas.CreateNewCanvas(canvasWidth, canvasWidth)
+	// both axes use the same scale, so the height of the canvas follows from the shape of the pond
+	scale := float64(canvasWidth) / p.width
+	canvasHeight := int(math.Max(1, math.Round(p.height*scale)))
+
+	// set a new canvas
+	c := canvas.CreateNewCanvas(canvasWidth, canvasHeight)
 
 	// create a black background
 	c.SetFillColor(canvas.MakeColor(0, 0, 0))
-	c.ClearRect(0, 0, canvasWidth, canvasWidth)
+	c.ClearRect(0, 0, canvasWidth, canvasHeight)
 	c.Fill()
 
+	// draw the obstacles below everything else
+	c.SetFillColor(canvas.MakeColor(100, 100, 100))
+	c.SetStrokeColor(canvas.MakeColor(100, 100, 100))
+	for _, o := range p.obstacles {
+		o.Draw(&c, scale)
+	}
+
 	// range over all the bodies and draw them.
 	for _, b := range p.swimbots {
 		if b != nil {
@@ -60,8 +102,10 @@ func (p *Pond) DrawToCanvas(canvasWidth int, scalingFactor float64) image.Image
 				// segGenes[i][4] = (rand.Float64() * 15.0) + 5.0 // should take on values from 5.0 to 20.0
 				// // width
 				// segGenes[i][5] = (rand.Float64() * 3.7) + 0.3 // should take on values from 0.3 to 4.0
-				cx := (sliceOfSegments[i].position.x / p.width) * float64(canvasWidth)
-				cy := (sliceOfSegments[i].position.y / p.width) * float64(canvasWidth)
+				// in a wrapping pond a bot on the edge sticks out at the opposite edge
+				position := p.WrapPosition(sliceOfSegments[i].position)
+				cx := position.x * scale
+				cy := position.y * scale
 
 				// r := scalingFactor * (1 / p.width) * float64(canvasWidth)
 				c.Segment(cx, cy, width, length, sliceOfSegments[i].angle)
@@ -73,10 +117,11 @@ func (p *Pond) DrawToCanvas(canvasWidth int, scalingFactor float64) image.Image
 	// range over all the bodies and draw them.
 	for _, f := range p.foodBits {
 		if f != nil {
-			c.SetFillColor(canvas.MakeColor(255, 255, 255))
-			cx := (f.position.x / p.width) * float64(canvasWidth)
-			cy := (f.position.y / p.width) * float64(canvasWidth)
-			r := scalingFactor * (1 / p.width) * float64(canvasWidth)
+			red, green, blue, size := f.Appearance()
+			c.SetFillColor(canvas.MakeColor(red, green, blue))
+			cx := f.position.x * scale
+			cy := f.position.y * scale
+			r := scalingFactor * scale * size
 			c.Circle(cx, cy, r)
 			c.Fill()
 		}
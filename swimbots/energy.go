@@ -0,0 +1,51 @@
+package main
+
+import (
+	"math"
+)
+
+// The mass models. With "segments" every segment weighs segmentMass, whatever its shape.
+// With "area" the mass grows with the area (length times width) of the segments of the body.
+const (
+	segmentsMass = "segments"
+	areaMass     = "area"
+)
+
+// meanSegmentArea is the average area of a segment drawn by RandomGenome.
+// With the area model a segment of this area weighs segmentMass, so an average body weighs the same in both models.
+const meanSegmentArea = (minSegmentLength + maxSegmentLength) / 2 * (minSegmentWidth + maxSegmentWidth) / 2
+
+// Mass returns the mass of the bot under the mass model of the config.
+// The body has to be built already, since only the expressed segments count.
+func (bot *Swimbot) Mass(config *SimulationConfig) float64 {
+	if config.massModel != areaMass {
+		return config.segmentMass * float64(bot.botGene.numSegments)
+	}
+	return config.segmentMass * bot.BodyArea() / meanSegmentArea
+}
+
+// BodyArea returns the sum of the areas of the segments of the body
+func (bot *Swimbot) BodyArea() float64 {
+	area := 0.0
+	for _, seg := range bot.mainSegment.CollectSegments(make([]*Segment, 0)) {
+		area += bot.segGenes[seg.index][4] * bot.segGenes[seg.index][5]
+	}
+	return area
+}
+
+// SpendEnergy charges the bot the energy of one generation. Swimming costs energyLossFactor times the square of
+// the speed, turning costs turningCost per radian, and staying alive costs basalMetabolism even when the bot
+// doesn't move. All three costs grow with the mass, so a big body needs more food.
+func (bot *Swimbot) SpendEnergy(speed, turned float64, config *SimulationConfig) {
+	bot.energy -= (config.energyLossFactor*speed*speed + config.turningCost*turned + config.basalMetabolism) * bot.mass
+}
+
+// turnAngle returns the angle in radians between the directions of two velocities, 0 if one of them is zero
+func turnAngle(before, after OrderedPair) float64 {
+	if (before.x == 0 && before.y == 0) || (after.x == 0 && after.y == 0) {
+		return 0
+	}
+	cross := before.x*after.y - before.y*after.x
+	dot := before.x*after.x + before.y*after.y
+	return math.Abs(math.Atan2(cross, dot))
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register the image formats a density image can be read from
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+// FoodModel decides where and when food appears in the pond.
+// A model is shared by every pond of a run and doesn't change during the run,
+// everything it needs to know about the food already in the pond it reads from the pond.
+// All the random numbers come from r, the food stream of the run.
+type FoodModel interface {
+	// InitialFood adds the food a new pond starts with
+	InitialFood(pond *Pond, r *rand.Rand)
+	// AddFood adds the food that appears in generation numGen
+	AddFood(pond *Pond, numGen int, r *rand.Rand)
+	// Validate checks the parameters of the model
+	Validate() error
+}
+
+// UniformFood throws amount food bits at random positions into the pond every frequency generations
+type UniformFood struct {
+	initial   int // number of food bits in a new pond
+	amount    int
+	frequency int
+}
+
+// PatchFood throws the food around a few fixed centers, so the food is clustered in patches.
+// The distance of a food bit from its center follows a normal distribution with standard deviation radius.
+type PatchFood struct {
+	centers   []OrderedPair
+	radius    float64
+	initial   int
+	amount    int
+	frequency int
+}
+
+// LogisticFood divides the pond into square cells that regrow their food every generation.
+// A cell with n food bits gets growthRate*n*(1-n/capacity) new ones, plus seedRate so empty cells recover as well.
+// Food grows fastest in cells that are half full and stops growing in full ones.
+type LogisticFood struct {
+	cellSize   float64
+	capacity   float64 // number of food bits a cell can hold
+	growthRate float64
+	seedRate   float64
+	initial    int // number of food bits per cell in a new pond
+}
+
+// SeasonalFood works like UniformFood, but the amount of food rises and falls over the seasons:
+// in generation numGen it throws amount*(1+amplitude*sin(2*pi*numGen/period)) food bits.
+type SeasonalFood struct {
+	initial   int
+	amount    int
+	frequency int
+	period    float64 // length of a year in generations
+	amplitude float64 // between 0 (no seasons) and 1 (no food at all in the middle of winter)
+}
+
+// DensityImageFood throws the food with a probability proportional to the brightness of an image stretched over the pond,
+// so food never appears where the image is black
+type DensityImageFood struct {
+	cols, rows int
+	cumulative []float64 // running sum of the brightness of the pixels, row by row
+	initial    int
+	amount     int
+	frequency  int
+}
+
+// NewUniformFood returns the food model of the original simulation
+func NewUniformFood(initial, amount, frequency int) *UniformFood {
+	return &UniformFood{initial, amount, frequency}
+}
+
+// InitialFood throws the initial food bits at random positions
+func (m *UniformFood) InitialFood(pond *Pond, r *rand.Rand) {
+	for i := 0; i < m.initial; i++ {
+		pond.DropFood(pond.RandomPosition(r, foodMargin), r)
+	}
+}
+
+// AddFood throws amount food bits at random positions every frequency generations
+func (m *UniformFood) AddFood(pond *Pond, numGen int, r *rand.Rand) {
+	if numGen%m.frequency == 0 {
+		for i := 0; i < m.amount; i++ {
+			pond.DropFood(pond.RandomPosition(r, foodMargin), r)
+		}
+	}
+}
+
+// Validate checks that the numbers of food bits are non-negative and the frequency positive
+func (m *UniformFood) Validate() error {
+	return validateDrops(m.initial, m.amount, m.frequency)
+}
+
+// NewPatchFood returns a model that throws the food around the given centers
+func NewPatchFood(centers []OrderedPair, radius float64, initial, amount, frequency int) *PatchFood {
+	return &PatchFood{centers, radius, initial, amount, frequency}
+}
+
+// RandomPatchCenters returns n random centers inside a pond of the given size, for NewPatchFood.
+// The same seed always gives the same centers.
+func RandomPatchCenters(n int, width, height float64, seed int64) []OrderedPair {
+	r := rand.New(rand.NewSource(seed))
+	centers := make([]OrderedPair, n)
+	for i := range centers {
+		centers[i] = OrderedPair{randomCoordinate(r, width, foodMargin), randomCoordinate(r, height, foodMargin)}
+	}
+	return centers
+}
+
+// InitialFood throws the initial food bits around the centers
+func (m *PatchFood) InitialFood(pond *Pond, r *rand.Rand) {
+	for i := 0; i < m.initial; i++ {
+		m.drop(pond, r)
+	}
+}
+
+// AddFood throws amount food bits around the centers every frequency generations
+func (m *PatchFood) AddFood(pond *Pond, numGen int, r *rand.Rand) {
+	if numGen%m.frequency == 0 {
+		for i := 0; i < m.amount; i++ {
+			m.drop(pond, r)
+		}
+	}
+}
+
+// drop throws one food bit around a random center, a food bit that keeps landing outside of the pond or in an obstacle is lost
+func (m *PatchFood) drop(pond *Pond, r *rand.Rand) {
+	center := m.centers[r.Intn(len(m.centers))]
+	for attempt := 0; attempt < maxPositionAttempts; attempt++ {
+		position := OrderedPair{center.x + r.NormFloat64()*m.radius, center.y + r.NormFloat64()*m.radius}
+		if pond.FreePosition(position) {
+			pond.DropFood(position, r)
+			return
+		}
+	}
+}
+
+// Validate checks that there is at least one center and the radius is positive
+func (m *PatchFood) Validate() error {
+	if len(m.centers) == 0 {
+		return fmt.Errorf("patch food needs at least one patch")
+	}
+	if m.radius <= 0 {
+		return fmt.Errorf("patch radius must be positive, got %v", m.radius)
+	}
+	return validateDrops(m.initial, m.amount, m.frequency)
+}
+
+// NewLogisticFood returns a model where the food regrows in every cell of the pond
+func NewLogisticFood(cellSize, capacity, growthRate, seedRate float64, initial int) *LogisticFood {
+	return &LogisticFood{cellSize, capacity, growthRate, seedRate, initial}
+}
+
+// InitialFood puts initial food bits into every cell
+func (m *LogisticFood) InitialFood(pond *Pond, r *rand.Rand) {
+	cols, rows := m.cells(pond)
+	for cell := 0; cell < cols*rows; cell++ {
+		for i := 0; i < m.initial; i++ {
+			m.drop(pond, cell%cols, cell/cols, r)
+		}
+	}
+}
+
+// AddFood counts the food of every cell and lets it regrow.
+// The fraction of a food bit that is left over is thrown with the matching probability.
+func (m *LogisticFood) AddFood(pond *Pond, numGen int, r *rand.Rand) {
+	cols, rows := m.cells(pond)
+	counts := make([]float64, cols*rows)
+	for _, f := range pond.foodBits {
+		if f != nil {
+			col := clampCell(f.position.x/m.cellSize, cols)
+			row := clampCell(f.position.y/m.cellSize, rows)
+			counts[row*cols+col]++
+		}
+	}
+	for cell, n := range counts {
+		// a full cell doesn't grow
+		if n >= m.capacity {
+			continue
+		}
+		growth := m.growthRate*n*(1-n/m.capacity) + m.seedRate
+		for i := 0; i < StochasticRound(growth, r); i++ {
+			m.drop(pond, cell%cols, cell/cols, r)
+		}
+	}
+}
+
+// cells returns the number of columns and rows of cells covering the pond
+func (m *LogisticFood) cells(pond *Pond) (int, int) {
+	return int(math.Ceil(pond.width / m.cellSize)), int(math.Ceil(pond.height / m.cellSize))
+}
+
+// drop throws one food bit at a random position of a cell, a food bit that keeps landing in an obstacle is lost
+func (m *LogisticFood) drop(pond *Pond, col, row int, r *rand.Rand) {
+	for attempt := 0; attempt < maxPositionAttempts; attempt++ {
+		position := OrderedPair{(float64(col) + r.Float64()) * m.cellSize, (float64(row) + r.Float64()) * m.cellSize}
+		if pond.FreePosition(position) {
+			pond.DropFood(position, r)
+			return
+		}
+	}
+}
+
+// Validate checks that the cells have a size and a capacity and the rates are non-negative
+func (m *LogisticFood) Validate() error {
+	if m.cellSize <= 0 || m.capacity <= 0 {
+		return fmt.Errorf("cell size and capacity of logistic food must be positive, got %v and %v", m.cellSize, m.capacity)
+	}
+	if m.growthRate < 0 || m.seedRate < 0 || m.initial < 0 {
+		return fmt.Errorf("growth rate, seed rate and initial food of logistic food must be non-negative")
+	}
+	return nil
+}
+
+// NewSeasonalFood returns a model that throws more food in summer than in winter
+func NewSeasonalFood(initial, amount, frequency int, period, amplitude float64) *SeasonalFood {
+	return &SeasonalFood{initial, amount, frequency, period, amplitude}
+}
+
+// InitialFood throws the initial food bits at random positions
+func (m *SeasonalFood) InitialFood(pond *Pond, r *rand.Rand) {
+	for i := 0; i < m.initial; i++ {
+		pond.DropFood(pond.RandomPosition(r, foodMargin), r)
+	}
+}
+
+// AddFood throws the food of the current season at random positions every frequency generations
+func (m *SeasonalFood) AddFood(pond *Pond, numGen int, r *rand.Rand) {
+	if numGen%m.frequency == 0 {
+		amount := float64(m.amount) * (1 + m.amplitude*math.Sin(2*math.Pi*float64(numGen)/m.period))
+		for i := 0; i < StochasticRound(amount, r); i++ {
+			pond.DropFood(pond.RandomPosition(r, foodMargin), r)
+		}
+	}
+}
+
+// Validate checks the drops, that a year has a length and that the amplitude is between 0 and 1
+func (m *SeasonalFood) Validate() error {
+	if m.period <= 0 {
+		return fmt.Errorf("season period must be positive, got %v", m.period)
+	}
+	if m.amplitude < 0 || m.amplitude > 1 {
+		return fmt.Errorf("season amplitude must be between 0 and 1, got %v", m.amplitude)
+	}
+	return validateDrops(m.initial, m.amount, m.frequency)
+}
+
+// NewDensityImageFood returns a model that throws the food following the brightness of img
+func NewDensityImageFood(img image.Image, initial, amount, frequency int) *DensityImageFood {
+	var m DensityImageFood
+	bounds := img.Bounds()
+	m.cols, m.rows = bounds.Dx(), bounds.Dy()
+	m.cumulative = make([]float64, 0, m.cols*m.rows)
+	total := 0.0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			total += float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+			m.cumulative = append(m.cumulative, total)
+		}
+	}
+	m.initial, m.amount, m.frequency = initial, amount, frequency
+	return &m
+}
+
+// ReadDensityImage reads a png, jpeg or gif image from a file and returns the food model following its brightness
+func ReadDensityImage(filename string, initial, amount, frequency int) (*DensityImageFood, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return NewDensityImageFood(img, initial, amount, frequency), nil
+}
+
+// InitialFood throws the initial food bits following the image
+func (m *DensityImageFood) InitialFood(pond *Pond, r *rand.Rand) {
+	for i := 0; i < m.initial; i++ {
+		m.drop(pond, r)
+	}
+}
+
+// AddFood throws amount food bits following the image every frequency generations
+func (m *DensityImageFood) AddFood(pond *Pond, numGen int, r *rand.Rand) {
+	if numGen%m.frequency == 0 {
+		for i := 0; i < m.amount; i++ {
+			m.drop(pond, r)
+		}
+	}
+}
+
+// drop picks a pixel with a probability proportional to its brightness and throws a food bit at a random position of the pixel.
+// A food bit that keeps landing in an obstacle is lost.
+func (m *DensityImageFood) drop(pond *Pond, r *rand.Rand) {
+	total := m.cumulative[len(m.cumulative)-1]
+	for attempt := 0; attempt < maxPositionAttempts; attempt++ {
+		u := r.Float64() * total
+		pixel := sort.Search(len(m.cumulative), func(i int) bool { return m.cumulative[i] > u })
+		col, row := pixel%m.cols, pixel/m.cols
+		position := OrderedPair{(float64(col) + r.Float64()) * pond.width / float64(m.cols), (float64(row) + r.Float64()) * pond.height / float64(m.rows)}
+		if pond.FreePosition(position) {
+			pond.DropFood(position, r)
+			return
+		}
+	}
+}
+
+// Validate checks the drops and that the image is not completely black
+func (m *DensityImageFood) Validate() error {
+	if len(m.cumulative) == 0 || m.cumulative[len(m.cumulative)-1] == 0 {
+		return fmt.Errorf("the density image has no bright pixel")
+	}
+	return validateDrops(m.initial, m.amount, m.frequency)
+}
+
+// validateDrops checks the parameters shared by the models that throw food every few generations
+func validateDrops(initial, amount, frequency int) error {
+	if initial < 0 || amount < 0 {
+		return fmt.Errorf("number of food bits must be non-negative, got %d and %d", initial, amount)
+	}
+	// the frequency is used as a modulus in AddFood
+	if frequency <= 0 {
+		return fmt.Errorf("food frequency must be positive, got %d", frequency)
+	}
+	return nil
+}
+
+// StochasticRound rounds x down or up, up with a probability equal to the fraction of x, so on average it returns x
+func StochasticRound(x float64, r *rand.Rand) int {
+	whole := math.Floor(x)
+	if r.Float64() < x-whole {
+		whole++
+	}
+	return int(whole)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// FoodType describes a kind of food: how much energy it gives, how it looks,
+// how often it appears compared to the other types and which bots can eat it.
+// A bot only eats food of a type whose rules all accept it, so different bodies can live on different food.
+type FoodType struct {
+	name             string
+	energy           float64
+	red, green, blue uint8
+	size             float64 // radius of the food bit in the drawing, plain food has size 1
+	weight           float64 // relative frequency of the type among the food thrown into the pond
+	rules            []EdibilityRule
+}
+
+// EdibilityRule returns true if the bot is able to eat the food of a type
+type EdibilityRule func(bot *Swimbot) bool
+
+// NewFoodType returns a food type that every bot can eat, add rules with AddRule
+func NewFoodType(name string, energy float64, red, green, blue uint8, size, weight float64) *FoodType {
+	return &FoodType{name: name, energy: energy, red: red, green: green, blue: blue, size: size, weight: weight}
+}
+
+// AddRule adds a rule a bot has to satisfy to eat the food of the type
+func (t *FoodType) AddRule(rule EdibilityRule) {
+	t.rules = append(t.rules, rule)
+}
+
+// CanEat returns true if the bot satisfies every rule of the type
+func (t *FoodType) CanEat(bot *Swimbot) bool {
+	for _, rule := range t.rules {
+		if !rule(bot) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks that the type has a name, a non-negative energy, a size and a weight
+func (t *FoodType) Validate() error {
+	if t.name == "" {
+		return fmt.Errorf("a food type needs a name")
+	}
+	if t.energy < 0 {
+		return fmt.Errorf("energy of food type %s must be non-negative, got %v", t.name, t.energy)
+	}
+	if t.size <= 0 || t.weight <= 0 {
+		return fmt.Errorf("size and weight of food type %s must be positive, got %v and %v", t.name, t.size, t.weight)
+	}
+	return nil
+}
+
+// EdibleBy returns true if the bot can eat the food bit. Plain food can be eaten by every bot.
+func (f *Food) EdibleBy(bot *Swimbot) bool {
+	return f.foodType == nil || f.foodType.CanEat(bot)
+}
+
+// Energy returns the energy the food bit gives, plain food gives plainEnergy
+func (f *Food) Energy(plainEnergy float64) float64 {
+	if f.foodType == nil {
+		return plainEnergy
+	}
+	return f.foodType.energy
+}
+
+// Appearance returns the colour and the size of the food bit in the drawing, plain food is a white dot of size 1
+func (f *Food) Appearance() (red, green, blue uint8, size float64) {
+	if f.foodType == nil {
+		return 255, 255, 255, 1
+	}
+	return f.foodType.red, f.foodType.green, f.foodType.blue, f.foodType.size
+}
+
+// RandomFoodType draws one of the types with a probability proportional to its weight.
+// It returns nil, plain food, if there are no types, and doesn't use r if there is only one.
+func RandomFoodType(types []*FoodType, r *rand.Rand) *FoodType {
+	if len(types) == 0 {
+		return nil
+	}
+	if len(types) == 1 {
+		return types[0]
+	}
+	total := 0.0
+	for _, t := range types {
+		total += t.weight
+	}
+	u := r.Float64() * total
+	for _, t := range types {
+		if u < t.weight {
+			return t
+		}
+		u -= t.weight
+	}
+	// rounding can leave a tiny rest
+	return types[len(types)-1]
+}
+
+// NewEdibilityRule returns the rule of the given kind with the given limit:
+// "minWidth" and "maxWidth" limit the width of the main segment,
+// "minSegments" and "maxSegments" the number of segments of the bot.
+func NewEdibilityRule(kind string, limit float64) (EdibilityRule, error) {
+	switch kind {
+	case "minWidth":
+		return func(bot *Swimbot) bool { return bot.segGenes[0][5] >= limit }, nil
+	case "maxWidth":
+		return func(bot *Swimbot) bool { return bot.segGenes[0][5] <= limit }, nil
+	case "minSegments":
+		return func(bot *Swimbot) bool { return float64(bot.botGene.numSegments) >= limit }, nil
+	case "maxSegments":
+		return func(bot *Swimbot) bool { return float64(bot.botGene.numSegments) <= limit }, nil
+	}
+	return nil, fmt.Errorf("unknown edibility rule %q", kind)
+}
+
+// ReadFoodTypes reads the food types of a pond from a file with one type per line:
+//
+//	name energy weight size red green blue [rule limit ...]
+//
+// followed by any number of edibility rules, see NewEdibilityRule. For example
+//
+//	algae 30 3 1 0 200 0 maxWidth 2
+//	shrimp 120 1 2 255 120 0 minWidth 2.5 minSegments 3
+//
+// Empty lines and lines starting with # are skipped.
+func ReadFoodTypes(filename string) ([]*FoodType, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	types := make([]*FoodType, 0)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		t, err := parseFoodType(fields)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filename, lineNumber, err)
+		}
+		types = append(types, t)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// parseFoodType builds a food type from the fields of a line, see ReadFoodTypes
+func parseFoodType(fields []string) (*FoodType, error) {
+	if len(fields) < 7 || len(fields)%2 != 1 {
+		return nil, fmt.Errorf("a food type needs a name, energy, weight, size, a colour and pairs of rules and limits")
+	}
+	values := make([]float64, 6)
+	for i := range values {
+		value, err := strconv.ParseFloat(fields[i+1], 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	for _, c := range values[3:] {
+		if c < 0 || c > 255 {
+			return nil, fmt.Errorf("colour values must be between 0 and 255, got %v", c)
+		}
+	}
+	t := NewFoodType(fields[0], values[0], uint8(values[3]), uint8(values[4]), uint8(values[5]), values[2], values[1])
+	for i := 7; i < len(fields); i += 2 {
+		limit, err := strconv.ParseFloat(fields[i+1], 64)
+		if err != nil {
+			return nil, err
+		}
+		rule, err := NewEdibilityRule(fields[i], limit)
+		if err != nil {
+			return nil, err
+		}
+		t.AddRule(rule)
+	}
+	return t, t.Validate()
+}
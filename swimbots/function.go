@@ -4,85 +4,165 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"sync"
 )
 
-// SimulatePond takes in initialPond, and simulate the artificial pond numGen of times.
-func SimulatePond(numGens int, time float64, numInitialBots, numFood int, viewRange float64, proximity float64, foodEnergy, hungerThreshold float64, maximumAge float64, foodFrequency int, segmentMass, energyLostFactor float64, matingPreference int) []*Pond {
-	// Create an initialized pond with specified number of bots
-	initialPond := InitializePond(numInitialBots, segmentMass)
-	timePoints := make([]*Pond, numGens+1)
-	timePoints[0] = initialPond
+// SimulatePond creates an initial pond from the config, and simulate the artificial pond config.numGens of times.
+// It keeps every pond of the run in memory; use a Simulator with observers to consume the ponds one at a time instead.
+func SimulatePond(config *SimulationConfig) []*Pond {
+	sim := NewSimulator(config)
+	var history HistoryRecorder
+	sim.AddObserver(&history)
 	//now range over the number of generations and update the pond each time
-	for i := 1; i <= numGens; i++ {
-		// fmt.Println("generation", i)
-		timePoints[i] = UpdatePond(timePoints[i-1], time, i,  numFood, viewRange, proximity, foodEnergy, hungerThreshold, maximumAge, foodFrequency, segmentMass, energyLostFactor, matingPreference)
-	}
-	return timePoints
+	sim.Run()
+	return history.TimePoints()
 }
 
 // UpdatePond update the pond to a new time point
-func UpdatePond(oldPond *Pond, time float64, numGen, numFood int, viewRange float64, proximity float64, foodEnergy, hungerThreshold float64, maximumAge float64, foodFrequency int, segmentMass, energyLossFactor float64, matingPreference int) *Pond {
+func UpdatePond(oldPond *Pond, numGen int, config *SimulationConfig) *Pond {
 	// create a new Pond
 	newPond := CopyPond(oldPond)
+	// index the old pond so each bot only looks at its surroundings when it picks a goal
+	oldPond.grid = NewSpatialGrid(oldPond, config.viewRange)
 
-	for i := range newPond.swimbots {
-		// if the bot already died, we skip updating the bot
-		if newPond.swimbots[i] == nil {
-			continue
-		}
-		// Set the goal for all the living bots in the pond
-		newPond.SetGoal(i, oldPond, viewRange, hungerThreshold, matingPreference)
-		// update the velocity and position
-		newPond.swimbots[i].UpdateVelocity(oldPond, energyLossFactor)
-		newPond.swimbots[i].UpdatePosition(time) // & ENERGY
-		// update age
-		newPond.swimbots[i].age += 1
-		// if a bot's energy reaches 0, kill the bot!
-		if newPond.swimbots[i].energy <= 0 || newPond.swimbots[i].age >= maximumAge {
-			newPond.swimbots[i] = nil
+	if config.numWorkers <= 1 {
+		for i := range newPond.swimbots {
+			// if the bot already died, we skip updating the bot
+			if newPond.swimbots[i] == nil {
+				continue
+			}
+			newPond.UpdateSwimbot(i, oldPond, numGen, config)
 		}
+	} else {
+		newPond.UpdateSwimbotsParallel(oldPond, numGen, config)
 	}
+	// the pedigree is shared by the whole run, so the deaths are recorded after the parallel part
+	newPond.RecordDeaths(oldPond, numGen)
+	// the old pond is not searched anymore, drop its index so the recorded history stays small
+	oldPond.grid = nil
 	// determine whether the bots can eat or mate
-	newPond.EatOrMate(proximity, foodEnergy, segmentMass)
+	newPond.EatOrMate(numGen, config)
 	// add food when we reach "FoodFrequency"
-	newPond.AddFood(numGen, numFood, foodFrequency)
+	newPond.AddFood(numGen, config)
+	// every compactionInterval generations, get rid of the dead bots and the eaten food
+	if config.compactionInterval > 0 && numGen%config.compactionInterval == 0 {
+		newPond.Compact()
+	}
 	return newPond
 }
 
+// UpdateSwimbot moves the living bot at index i of newPond one generation forward.
+// It only reads oldPond and only writes the bot's own slot of newPond, so bots can be updated in any order.
+func (newPond *Pond) UpdateSwimbot(i int, oldPond *Pond, numGen int, config *SimulationConfig) {
+	// every bot draws from its own stream so the result doesn't depend on the update order
+	r := newPond.rng.BotStream(numGen, newPond.swimbots[i].id)
+	// Set the goal for all the living bots in the pond
+	// with behaviour genes every bot sees, gets hungry and chooses its mate in its own way
+	viewRange, hungerThreshold, preference := newPond.swimbots[i].Behaviour(config)
+	newPond.SetGoal(i, oldPond, viewRange, hungerThreshold, preference, r)
+	// update the velocity and position
+	newPond.swimbots[i].UpdateVelocity(oldPond, config)
+	newPond.swimbots[i].UpdatePosition(config.time, newPond) // & ENERGY
+	// keep the bot in the pond, a bot absorbed by the edge dies
+	alive := newPond.ApplyBoundary(newPond.swimbots[i])
+	// update age
+	newPond.swimbots[i].age += 1
+	newPond.swimbots[i].ForgetRejections()
+	// if a bot's energy reaches 0, kill the bot!
+	if !alive || newPond.swimbots[i].energy <= 0 || newPond.swimbots[i].age >= config.maximumAge {
+		newPond.swimbots[i] = nil
+	}
+}
+
+// UpdateSwimbotsParallel updates every living bot of newPond with config.numWorkers goroutines.
+// It produces exactly the same pond as calling UpdateSwimbot on every bot one after the other.
+func (newPond *Pond) UpdateSwimbotsParallel(oldPond *Pond, numGen int, config *SimulationConfig) {
+	jobs := make(chan int, config.numWorkers)
+	var wg sync.WaitGroup
+
+	for w := 0; w < config.numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				newPond.UpdateSwimbot(i, oldPond, numGen, config)
+			}
+		}()
+	}
+
+	// hand out the living bots to the workers
+	for i := range newPond.swimbots {
+		if newPond.swimbots[i] != nil {
+			jobs <- i
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// RecordDeaths records in the pedigree every bot that was alive in oldPond and died while newPond was updated.
+// It must be called before anything is added to newPond or newPond is compacted.
+func (newPond *Pond) RecordDeaths(oldPond *Pond, numGen int) {
+	for i := range oldPond.swimbots {
+		if oldPond.swimbots[i] != nil && newPond.swimbots[i] == nil {
+			newPond.pedigree.RecordDeath(oldPond.swimbots[i].id, numGen)
+		}
+	}
+}
+
 // EatOrMate let the swimbot eat or mates at this generation
-func (pond *Pond) EatOrMate(proximity float64, foodEnergy, segmentMass float64) {
+// numGen is the current generation, which becomes the birth step of the children
+func (pond *Pond) EatOrMate(numGen int, config *SimulationConfig) {
 	// make a slice of swimbots that has already mated for this generation
 	// because we don't want them to give twins or give two bots in one generation
-	alreadyGotLucky := make([]bool, len(pond.swimbots))
+	alreadyGotLucky := make(map[int]bool)
 
 	// range through all the swimbots
 	for i := range pond.swimbots {
 		// determine only if the swimbot is not nil
 		if pond.swimbots[i] != nil {
 			// if the swimbot doesn't have a visible goal we skip
-			if pond.swimbots[i].goal.index == -1 {
+			if pond.swimbots[i].goal.id == -1 {
 				continue
+			} else if pond.swimbots[i].goal.isPrey { // the bot hunts
+				prey := pond.SwimbotByID(pond.swimbots[i].goal.id)
+				if prey != nil && pond.swimbots[i].GoalDistance(pond) <= config.proximity {
+					pond.Attack(i, prey, numGen, config)
+				}
 			} else if pond.swimbots[i].goal.isBot { // the goal of the bot isBot
 				// There are four scenarios that we need to satisfy in order to mate
 				// 1. The goal swimbot still exist (not nil)
 				// 2. It's within proximity
 				// 3. The swimbot haven't mate in this round
 				// 4. The goal swimbot haven't mate in this round
-				if pond.swimbots[pond.swimbots[i].goal.index] != nil && pond.swimbots[i].GoalDistance(pond) <= proximity && alreadyGotLucky[i] == false && alreadyGotLucky[pond.swimbots[i].goal.index] == false {
-					childIndex := len(pond.swimbots)
-					// Generate a child through mating
-					child := pond.Mating(i, pond.swimbots[i].goal.index, childIndex, segmentMass)
-					pond.swimbots = append(pond.swimbots, child)
+				// 5. They are genetically close enough to mate
+				mate := pond.SwimbotByID(pond.swimbots[i].goal.id)
+				if mate != nil && pond.swimbots[i].GoalDistance(pond) <= config.proximity && alreadyGotLucky[pond.swimbots[i].id] == false && alreadyGotLucky[mate.id] == false && pond.swimbots[i].CompatibleWith(mate, pond) {
+					// with mutual choice the goal swimbot gets a say as well
+					if config.mutualChoice {
+						// the goal judges the suitor with its own view range and preference
+						mateViewRange, _, matePreference := mate.Behaviour(config)
+						if !mate.AcceptsSuitor(pond.swimbots[i], pond, mateViewRange, config.acceptFraction, matePreference, pond.rng.choice) {
+							pond.swimbots[i].Reject(mate.id, config.rejectionCost)
+							continue
+						}
+					}
+					// Generate a child through mating, it gets the next free ID when we add it to the pond
+					child := pond.Mating(i, pond.botSlots[mate.id], pond.nextBotID, config)
+					pond.AddSwimbot(child)
+					// the bot that chose its mate is recorded as the mother
+					pond.pedigree.RecordBirth(child.id, pond.swimbots[i].id, mate.id, numGen)
 					// record the mating swimbots
-					alreadyGotLucky[i] = true
-					alreadyGotLucky[pond.swimbots[i].goal.index] = true
+					alreadyGotLucky[pond.swimbots[i].id] = true
+					alreadyGotLucky[mate.id] = true
 				}
 			} else { // the goal of the bot is food
 				// if the foodbit is not nil
-				if pond.foodBits[pond.swimbots[i].goal.index] != nil && pond.swimbots[i].GoalDistance(pond) <= proximity {
-					pond.swimbots[i].energy += foodEnergy
-					// we are using integer as an goal
-					pond.foodBits[pond.swimbots[i].goal.index] = nil
+				food := pond.FoodByID(pond.swimbots[i].goal.id)
+				if food != nil && pond.swimbots[i].GoalDistance(pond) <= config.proximity {
+					pond.swimbots[i].energy += food.Energy(config.foodEnergy)
+					// the goal holds the ID of the food bit
+					pond.RemoveFoodByID(pond.swimbots[i].goal.id)
 				}
 			}
 		}
@@ -90,63 +170,117 @@ func (pond *Pond) EatOrMate(proximity float64, foodEnergy, segmentMass float64)
 }
 
 // InitializePond generate randomized swimbots and foodbits at random positions
-func InitializePond(numBots int, segmentMass float64) *Pond {
+func InitializePond(config *SimulationConfig) *Pond {
 	var p Pond
-	p.width = 6000
+	p.width = config.width
+	p.height = config.height
+	p.boundary = config.boundary
+	p.obstacles = config.obstacles
+	p.foodTypes = config.foodTypes
+	p.speciesThreshold = config.speciesThreshold
+	// every pond of this run shares the random streams seeded by the user
+	p.rng = NewRandomStreams(config.seed)
+	p.pedigree = NewPedigree()
 	initialEnergy := 75.0
 
+	// with several species, every species descends from its own founder
+	var founders []*Swimbot
+	if config.numSpecies > 1 {
+		founders = RandomFounders(config.numSpecies, p.rng.genome)
+	}
+
 	// Initialize swimbots and append them to the slice
-	for i := 0; i < numBots; i++ {
-		p.swimbots = append(p.swimbots, InitializeSwimbot(initialEnergy, segmentMass))
-		p.swimbots[i].family = append(p.swimbots[i].family, i)
+	for i := 0; i < config.numInitialBots; i++ {
+		position := p.RandomPosition(p.rng.spawn, spawnMargin)
+		common, segGenes := InitialGenome(founders, i, config, p.rng.genome)
+		brainGenes := InitialBrain(config, p.rng.genome)
+		// a diploid bot draws a second copy of its genome the same way, and expresses both
+		var haplotypes []Haplotype
+		if config.diploid {
+			common2, segGenes2 := InitialGenome(founders, i, config, p.rng.genome)
+			haplotypes = []Haplotype{{common, segGenes, brainGenes}, {common2, segGenes2, InitialBrain(config, p.rng.genome)}}
+			common, segGenes = Express(haplotypes, config.dominance)
+			brainGenes = ExpressBrain(haplotypes)
+		}
+		bot := NewSwimbot(position, initialEnergy, config.segmentMass, common, segGenes, p.rng)
+		bot.brainGenes = brainGenes
+		bot.haplotypes = haplotypes
+		bot.mass = bot.Mass(config)
+		// the initial predators are pure carnivores, everybody else eats only food
+		if config.predation && p.rng.spawn.Float64() < config.initialPredators {
+			bot.botGene.diet = maxDiet
+			for k := range bot.haplotypes {
+				bot.haplotypes[k].botGene.diet = maxDiet
+			}
+		}
+		p.AddSwimbot(bot)
+		bot.family = append(bot.family, bot.id)
+		p.pedigree.RecordBirth(bot.id, -1, -1, 0)
 	}
 
 	// generate food
-	for i := 0; i < 400; i++ {
-		var f Food
-		// random positions whithin the slightly bigger square from origin
-		f.position.x = float64(rand.Intn(5000) + 500)
-		f.position.y = float64(rand.Intn(5000) + 500)
-		p.foodBits = append(p.foodBits, &f)
-	}
+	config.FoodModel().InitialFood(&p, p.rng.food)
 	return &p
 }
 
-// Mating takes in the index of two swimbots, a index of the child and produce a offspring
-func (pond *Pond) Mating(s1, s2 int, childIndex int, segmentMass float64) *Swimbot {
+// InitialGenome returns the genome of the initial bot i: a variant of the founder of its species,
+// or a random genome if there are no founders. With behaviour genes, they are drawn around the global parameters.
+func InitialGenome(founders []*Swimbot, i int, config *SimulationConfig, r *rand.Rand) (CommonGene, []SegmentGene) {
+	var common CommonGene
+	var segGenes []SegmentGene
+	if founders == nil {
+		common, segGenes = RandomGenome(r)
+	} else {
+		common, segGenes = FounderVariant(founders[i%len(founders)], config.founderSpread, r)
+	}
+	if config.behaviourGenes {
+		RandomBehaviour(&common, config, r)
+	}
+	return common, segGenes
+}
+
+// InitialBrain returns the random weights of the neural network of an initial bot, or nil without neural steering
+func InitialBrain(config *SimulationConfig, r *rand.Rand) []float64 {
+	if config.steering != neuralSteering {
+		return nil
+	}
+	return RandomBrainGenes(r)
+}
+
+// Mating takes in the index of two swimbots, the ID the child will get and produce a offspring
+func (pond *Pond) Mating(s1, s2 int, childID int, config *SimulationConfig) *Swimbot {
 	// calculate the energy for the children
 
 	bot1 := pond.swimbots[s1]
 	bot2 := pond.swimbots[s2]
 
 	childEnergy := bot1.energy/2 + bot2.energy/2
-	// update the parent's energy level
-	bot1.energy *= 0.5
-	bot2.energy *= 0.5
+	// update the parent's energy level, giving birth costs each parent the reproduction cost on top
+	bot1.energy = bot1.energy*0.5 - config.reproductionCost
+	bot2.energy = bot2.energy*0.5 - config.reproductionCost
 
-	// fmt.Println("S1's main segment genome:", pond.swimbots[s1].segGenes[0])
-	// fmt.Println("S2's main segment genome:", pond.swimbots[s2].segGenes[0])
-
-	child := GenerateChild(bot1, bot2, childEnergy, segmentMass)
+	child := GenerateChild(bot1, bot2, childEnergy, config, pond.rng)
+	// in a wrapping pond the parents might meet across an edge, so the pond decides where halfway is
+	child.position = pond.Midpoint(bot1.position, bot2.position)
+	child.UpdateSegmentPositions()
 
 	// we append the two parents to the family of the child
-	child.family = append(child.family, s1)
-	child.family = append(child.family, s2)
-	child.family = append(child.family, childIndex)
+	child.family = append(child.family, bot1.id)
+	child.family = append(child.family, bot2.id)
+	child.family = append(child.family, childID)
 
 	// append the child to the family of the parents
-	bot1.family = append(bot1.family, childIndex)
-	bot2.family = append(bot2.family, childIndex)
-	// fmt.Printf("A new child%d was given birth by parent %d and %d,cheers!\n", childIndex, s1, s2)
-	// fmt.Println("S1's main segment genome:", pond.swimbots[s1].segGenes[0])
-	// fmt.Println("S2's main segment genome:", pond.swimbots[s2].segGenes[0])
-	// fmt.Println("Children main segment genome:", child.segGenes[0])
+	bot1.family = append(bot1.family, childID)
+	bot2.family = append(bot2.family, childID)
+	// a bot that found a mate starts courting with a clean slate
+	bot1.rejectedBy = nil
+	bot2.rejectedBy = nil
 
 	return child
 }
 
 // GenerateChild generate a children bot based on its parents' genome and return its pointer
-func GenerateChild(s1, s2 *Swimbot, energy float64, segmentMass float64) *Swimbot {
+func GenerateChild(s1, s2 *Swimbot, energy float64, config *SimulationConfig, rng *RandomStreams) *Swimbot {
 	var child Swimbot
 	// set age and energy
 	child.age = 0
@@ -159,21 +293,53 @@ func GenerateChild(s1, s2 *Swimbot, energy float64, segmentMass float64) *Swimbo
 	// velocity= s1.velocity + s2.velocity/2.0
 	// acceleration= s1.acceleration + s2.acceleration/2.0
 
-	// Generate the genes for the child
-	child.segGenes, child.botGene = GenerateOffspringGenome(s1, s2)
+	// recombination only shuffles the parents' traits, mutations bring in new variation
+	mutation := config.mutation
+	if !config.predation {
+		// without predation the diet means nothing, it stays 0 and doesn't draw from the stream
+		mutation.diet.rate = 0
+	}
+	if !config.behaviourGenes {
+		// the same holds for the behaviour genes, the bots use the global parameters instead
+		mutation.viewRange.rate = 0
+		mutation.hungerThreshold.rate = 0
+		mutation.preference = 0
+	}
+	if config.diploid {
+		// every parent passes on one copy of its genome, and the child expresses the two copies it got
+		child.haplotypes = []Haplotype{s1.Gamete(config, rng.genome), s2.Gamete(config, rng.genome)}
+		for k := range child.haplotypes {
+			h := &child.haplotypes[k]
+			h.segGenes = MutateGenome(&h.botGene, h.segGenes, mutation, rng.genome)
+			MutateBrainGenes(h.brainGenes, mutation.brain, rng.genome)
+		}
+		child.botGene, child.segGenes = Express(child.haplotypes, config.dominance)
+		child.brainGenes = ExpressBrain(child.haplotypes)
+	} else {
+		// Generate the genes for the child
+		child.segGenes, child.botGene = GenerateOffspringGenome(s1, s2, rng.genome)
+		if config.behaviourGenes {
+			InheritBehaviour(&child.botGene, s1.botGene, s2.botGene, rng.genome)
+		}
+		child.segGenes = MutateGenome(&child.botGene, child.segGenes, mutation, rng.genome)
+		// the weights of the brain are recombined and mutated like the other genes, bots without a brain skip this
+		child.brainGenes = CrossBrainGenes(s1.brainGenes, s2.brainGenes, rng.genome)
+		MutateBrainGenes(child.brainGenes, mutation.brain, rng.genome)
+	}
 
 	// randomize the initial velocity of the child
-	child.velocity.x = (rand.Float64()-0.5) * child.botGene.translationalMovement
-	child.velocity.y = (rand.Float64()-0.5) * child.botGene.translationalMovement
+	child.velocity.x = (rng.spawn.Float64()-0.5) * child.botGene.translationalMovement
+	child.velocity.y = (rng.spawn.Float64()-0.5) * child.botGene.translationalMovement
 
-	// calculate the mass of the child
-	child.mass = segmentMass * float64(child.botGene.numSegments)
 	// build the segments for the bot
 	child.BuildSegments()
+	// calculate the mass of the child, which can depend on the shape of its body
+	child.mass = child.Mass(config)
 	return &child
 }
 
-// BuildSegments builds the body (the arrangement of segments) of a bot based on its expressing segmentgenes
+// BuildSegments builds the body (the arrangement of segments) of a bot based on its expressing segmentgenes.
+// Where each segment attaches is decided by its attachment gene, so the same genome always gives the same body.
 func (bot *Swimbot) BuildSegments() {
 	var mainSeg Segment
 	bot.mainSegment = &mainSeg
@@ -191,26 +357,11 @@ func (bot *Swimbot) BuildSegments() {
 	}
 	bot.mainSegment.angle = angle
 
+	// the segments built so far, in the order of their index
+	segments := []*Segment{bot.mainSegment}
 	for i := 1; i < bot.botGene.numSegments; i++ {
-		//currentSegment is a pointer of the current segment of the bot we are looking at
-		//it always starts with the main segment
-		currentSegment := bot.mainSegment
-		//for each segment to be added, we need to determine which existing segment to attach onto by flipping coins.
-		for currentSegment.subSegments != nil { // have subSegments
-			//First, flip a coin.
-			FlipaCoin := rand.Intn(2)
-			//If coin == 1, then we will continue looking at the subsegments of the current segment.
-			if FlipaCoin == 1 {
-				//randomly choose a subsegment to continue
-				segIndex := rand.Intn(len(currentSegment.subSegments))
-				currentSegment = currentSegment.subSegments[segIndex]
-			} else {
-				//if coin == 0, we will stop at the current segment
-				break
-			}
-			//or when the currentsegment does not have subsegments, we will stop at the current segment.
-		}
-		// doesn't have subSegments
+		// the attachment gene picks one of the segments that already exist
+		currentSegment := segments[AttachmentIndex(bot.segGenes[i][8], i)]
 		var newSegment Segment
 		//the index of the segment corresponds to the sepecific segmentgene
 		newSegment.index = i
@@ -218,7 +369,22 @@ func (bot *Swimbot) BuildSegments() {
 
 		//attach the segment to the currentsegment
 		currentSegment.subSegments = append(currentSegment.subSegments, &newSegment)
+		segments = append(segments, &newSegment)
+	}
+}
+
+// AttachmentIndex returns the index of the segment that segment i attaches to: the attachment gene,
+// between 0 and 1, is spread evenly over the segments 0 to i-1.
+// A child that inherits the gene from its parent also inherits where the segment sits on the body.
+func AttachmentIndex(attachment float64, i int) int {
+	index := int(attachment * float64(i))
+	if index > i-1 {
+		index = i - 1
+	}
+	if index < 0 {
+		index = 0
 	}
+	return index
 }
 
 // CalculateSegmentPosition takes in an old segment and calculate the position of the current segment based on the segment it is attached to
@@ -241,7 +407,8 @@ func (newSeg *Segment) CalculateSegmentPosition(currentSegment *Segment, segGene
 }
 
 // func GenerateOffspringGenome(botGene1, botGene2 CommonGene, segGene1, segGene2 SegmentGenes) (SegmentGene, CommonGene){
-func GenerateOffspringGenome(s1, s2 *Swimbot) ([]SegmentGene, CommonGene) {
+// the choices between the parents and the crossover points are drawn from r
+func GenerateOffspringGenome(s1, s2 *Swimbot, r *rand.Rand) ([]SegmentGene, CommonGene) {
 
 	// do for the three genes:
 	//   choose random number between 0 and 1
@@ -251,32 +418,45 @@ func GenerateOffspringGenome(s1, s2 *Swimbot) ([]SegmentGene, CommonGene) {
 	// for the three common gene we are choosing randomly from the parent
 	var offspringCommonGene CommonGene
 
-	num := rand.Intn(2)
+	num := r.Intn(2)
 	if num == 0 {
 		offspringCommonGene.angularMovement = s1.botGene.angularMovement
 	} else {
 		offspringCommonGene.angularMovement = s2.botGene.angularMovement
 	}
 
-	num = rand.Intn(2)
+	num = r.Intn(2)
 	if num == 0 {
 		offspringCommonGene.translationalMovement = s1.botGene.translationalMovement
 	} else {
 		offspringCommonGene.translationalMovement = s2.botGene.translationalMovement
 	}
 
-	num = rand.Intn(2)
+	// the child takes the number of segments and the length of its genome from the same parent,
+	// so it never expresses more segments than it has genes
+	lengthParent := s1
+	num = r.Intn(2)
 	if num == 0 {
 		offspringCommonGene.numSegments = s1.botGene.numSegments
 	} else {
 		offspringCommonGene.numSegments = s2.botGene.numSegments
+		lengthParent = s2
 	}
 
+	// the diet blends, so the child of a predator and a prey eats a bit of both
+	offspringCommonGene.diet = (s1.botGene.diet + s2.botGene.diet) * 0.5
+
 	// Each of the SegmentGenes is generated by combining the corresponding segmentgenes of parents.
-	offspringSegmentGene := make([]SegmentGene, 8)
+	// The genomes are aligned from the main segment on, so gene i of the child comes from gene i of both parents,
+	// and the genes that only the longer parent has are copied from it.
+	offspringSegmentGene := make([]SegmentGene, len(lengthParent.segGenes))
 	for i := range offspringSegmentGene {
+		if i >= len(s1.segGenes) || i >= len(s2.segGenes) {
+			offspringSegmentGene[i] = append(SegmentGene{}, lengthParent.segGenes[i]...)
+			continue
+		}
 		//for each segmentgene, a random crossoverpoint is generated.
-		crosspoint := rand.Intn(6)
+		crosspoint := r.Intn(len(s1.segGenes[i]))
 		//The 0-crosspoint part of the segmentgene will be inherited from one parent and the rest from the other parent.
 		offspringSegmentGene[i] = GenerateSegmentGene(s1.segGenes[i], s2.segGenes[i], crosspoint)
 	}
@@ -297,10 +477,15 @@ func GenerateSegmentGene(s1Gene, s2Gene SegmentGene, crosspoint int) SegmentGene
 }
 
 // UpdateVelocity updates the velocity f the bot
-func (bot *Swimbot) UpdateVelocity(pond *Pond, energyLossFactor float64) {
+func (bot *Swimbot) UpdateVelocity(pond *Pond, config *SimulationConfig) {
+	speed, turning := bot.Locomotion(config)
+	oldVelocity := bot.velocity
+	// a bot with a brain steers by what it senses instead of the fixed rule below
 	// if the goal is -1, it couldn't find a goal
 	// keep swimming towards the same direction
-	if bot.goal.index != -1 {
+	if brain := bot.Brain(config); brain != nil {
+		bot.SteerWithBrain(brain, pond, speed, turning, config)
+	} else if bot.goal.id != -1 {
 		// swim towards its goal
 		var deltax float64
 		var deltay float64
@@ -309,72 +494,83 @@ func (bot *Swimbot) UpdateVelocity(pond *Pond, energyLossFactor float64) {
 		// if the goal of the bot is a bot
 		case true:
 			// calculate the distance against the bot
-			deltax = pond.swimbots[bot.goal.index].position.x - bot.position.x
-			deltay = pond.swimbots[bot.goal.index].position.y - bot.position.y
+			delta := pond.Displacement(bot.position, pond.SwimbotByID(bot.goal.id).position)
+			deltax, deltay = delta.x, delta.y
 		// if the goal of the bot is a food
 		case false:
 			// calculate the distance against the food
-			deltax = pond.foodBits[bot.goal.index].position.x - bot.position.x
-			deltay = pond.foodBits[bot.goal.index].position.y - bot.position.y
+			delta := pond.Displacement(bot.position, pond.FoodByID(bot.goal.id).position)
+			deltax, deltay = delta.x, delta.y
 
 		}
 		// we calculate new and old angle by calculating acosine
 		newangle := math.Acos(deltax / bot.GoalDistance(pond))
-		oldangle := math.Acos(bot.velocity.x / bot.botGene.translationalMovement)
+		// with the genes the bot always swims at the same speed, with its body the speed changes every generation
+		currentSpeed := bot.botGene.translationalMovement
+		if config.locomotion == morphologyLocomotion {
+			currentSpeed = math.Sqrt(bot.velocity.x*bot.velocity.x + bot.velocity.y*bot.velocity.y)
+		}
+		oldangle := math.Acos(bot.velocity.x / currentSpeed)
 
 		// handle if the newAngle return NaN
 		if math.IsNaN(newangle) {
-			fmt.Println("goal is", bot.goal.index)
-			fmt.Println("The bot position are:", bot.position, pond.swimbots[bot.goal.index].position)
+			fmt.Println("goal is", bot.goal.id)
+			fmt.Println("The bot position are:", bot.position, bot.goal)
 			panic("New angle is not a number!")
 		}
 		if math.IsNaN(oldangle) {
 			fmt.Println("Bot velocity is", bot.velocity.x)
-			fmt.Println("Translational movement is ", bot.botGene.translationalMovement)
+			fmt.Println("Speed is ", currentSpeed)
 			fmt.Println(oldangle)
 			panic("Old angle is not a number!")
 		}
 		// Restrict the turning angle with angularMovement gene
-		if math.Abs(newangle-oldangle) > bot.botGene.angularMovement {
+		if math.Abs(newangle-oldangle) > turning {
 			if newangle-oldangle <= 0 {
-				newangle = oldangle - bot.botGene.angularMovement
+				newangle = oldangle - turning
 			} else {
-				newangle = oldangle + bot.botGene.angularMovement
+				newangle = oldangle + turning
 			}
 		}
 
 		bot.mainSegment.angle = newangle
 
 		// Calculate the velocity using the new restricted angle
-		bot.velocity.x = bot.botGene.translationalMovement * math.Cos(newangle)
+		bot.velocity.x = speed * math.Cos(newangle)
 		if deltay < 0 {
-			bot.velocity.y = -bot.botGene.translationalMovement * math.Sin(newangle)
+			bot.velocity.y = -speed * math.Sin(newangle)
 		} else {
-			bot.velocity.y = bot.botGene.translationalMovement * math.Sin(newangle)
+			bot.velocity.y = speed * math.Sin(newangle)
 
 		}
 
-	} else {
-		if bot.position.x >= pond.width || bot.position.x <= 0 {
-			bot.velocity.x = -bot.velocity.x
-		}
-		if bot.position.y >= pond.width || bot.position.y <= 0{
-			bot.velocity.y = -bot.velocity.y
-		}
 	}
-	// decrease the energy according to the speed.
-	speed := math.Sqrt(math.Pow(bot.velocity.x, 2)+ math.Pow(bot.velocity.y, 2))
-	// the loss of energy is porportioned to the square of speed and bot's mass
-	bot.energy -= energyLossFactor*speed*speed*bot.mass
+	// without a goal the bot keeps its velocity, the boundary of the pond turns it around at the edge
+	// decrease the energy according to the speed and the turn, see SpendEnergy
+	speed = math.Sqrt(math.Pow(bot.velocity.x, 2)+ math.Pow(bot.velocity.y, 2))
+	bot.SpendEnergy(speed, turnAngle(oldVelocity, bot.velocity), config)
 }
 
-// UpdatePosition update the position of a swimbot based on its velocity
-func (bot *Swimbot) UpdatePosition(time float64) {
-	bot.position.x += bot.velocity.x * time
-	bot.position.y += bot.velocity.y * time
+// UpdatePosition update the position of a swimbot based on its velocity and the obstacles of the pond
+func (bot *Swimbot) UpdatePosition(time float64, pond *Pond) {
+	newPosition := OrderedPair{bot.position.x + bot.velocity.x*time, bot.position.y + bot.velocity.y*time}
+	// a bot that would swim into an obstacle stays where it is and bounces off,
+	// a bot that is stuck inside one (it could only be put there) may swim out
+	if obstacle, contact := pond.Contact(bot.position, newPosition); obstacle != nil && !pond.Blocked(bot.position) {
+		// it bounces off the part of the obstacle it touches
+		normal := obstacle.Normal(contact)
+		dot := bot.velocity.x*normal.x + bot.velocity.y*normal.y
+		if dot < 0 {
+			bot.velocity.x -= 2 * dot * normal.x
+			bot.velocity.y -= 2 * dot * normal.y
+		} else {
+			// it touches right where two parts meet, turn around instead of running into them again every generation
+			bot.velocity.x, bot.velocity.y = -bot.velocity.x, -bot.velocity.y
+		}
+	} else {
+		bot.position = newPosition
+	}
 
-	bot.mainSegment.position.x = bot.position.x
-	bot.mainSegment.position.y = bot.position.y
 	// handle the case where velocity is updated to not a number
 	if math.IsNaN(bot.velocity.x){
 		fmt.Println("Velocity == NaN")
@@ -383,6 +579,14 @@ func (bot *Swimbot) UpdatePosition(time float64) {
 		fmt.Println("Velocity == NaN")
 	}
 
+	bot.UpdateSegmentPositions()
+}
+
+// UpdateSegmentPositions moves the segments of the bot along with its position
+func (bot *Swimbot) UpdateSegmentPositions() {
+	bot.mainSegment.position.x = bot.position.x
+	bot.mainSegment.position.y = bot.position.y
+
 	for i := range bot.mainSegment.subSegments {
 		bot.mainSegment.subSegments[i].UpdateSegmentPosition(bot.mainSegment, bot.segGenes)
 	}
@@ -397,21 +601,19 @@ func (segment *Segment)UpdateSegmentPosition(prevSegment *Segment, segGene []Seg
 	}
 }
 
-// InitializeSwimbot generate a swimbot with randomize genome at a random position and
-func InitializeSwimbot(initialEnergy, segmentMass float64) *Swimbot {
+// NewSwimbot generates a swimbot with the given genome at the given position
+func NewSwimbot(position OrderedPair, initialEnergy, segmentMass float64, common CommonGene, segGenes []SegmentGene, rng *RandomStreams) *Swimbot {
 	var bot Swimbot
-
-	bot.age = 0.0 // couldn't this be an int?
 	bot.energy = initialEnergy
+	bot.position = position
+	bot.botGene, bot.segGenes = common, segGenes
+	bot.setUp(segmentMass, rng)
+	return &bot
+}
 
-	bot.position.x = float64(rand.Intn(4000) + 1000) // random positions to each swimbot within the given radius
-	bot.position.y = float64(rand.Intn(4000) + 1000)
-
-	// generate genome for the bot
-	bot.botGene, bot.segGenes = RandomGenome()
-
-
-	angle := rand.Float64()*2*math.Pi
+// setUp gives a new bot with a genome a random direction, its mass and its body
+func (bot *Swimbot) setUp(segmentMass float64, rng *RandomStreams) {
+	angle := rng.spawn.Float64()*2*math.Pi
 	bot.velocity.x = math.Cos(angle) * bot.botGene.translationalMovement
 	bot.velocity.y = math.Sin(angle) * bot.botGene.translationalMovement
 
@@ -419,68 +621,74 @@ func InitializeSwimbot(initialEnergy, segmentMass float64) *Swimbot {
 	bot.mass = segmentMass * float64(bot.botGene.numSegments)
 
 	bot.BuildSegments()
-
-	return &bot
 }
 
-// RandomGenome generates a random genome for the initialization of swimbots
-func RandomGenome() (CommonGene, []SegmentGene) {
+// RandomGenome generates a random genome for the initialization of swimbots, drawing every trait from r
+func RandomGenome(r *rand.Rand) (CommonGene, []SegmentGene) {
 	var common CommonGene
 	// we have to multiply this by something when we calculate velocity, maybe 45 degree?
-	common.angularMovement = rand.Float64() * math.Pi / 4.0
+	common.angularMovement = r.Float64() * maxAngularMovement
 	// let's time 10 when we scale it
-	common.translationalMovement = rand.Float64() * 10
+	common.translationalMovement = r.Float64()*(maxTranslationalMovement-minTranslationalMovement) + minTranslationalMovement
 	// should generate values 2 to 8
-	common.numSegments = rand.Intn(7) + 2
-	// each bot have 8 segement gene
-	segGenes := make([]SegmentGene, 8)
-	// each segment gene have 6 traits
-	for i := 0; i < 8; i++ {
-		segGenes[i] = make(SegmentGene, 6)
-		redInt := rand.Intn(256)
-		greenInt := rand.Intn(256)
-		blueInt := rand.Intn(256)
+	common.numSegments = r.Intn(initialGenomeLength-minNumSegments+1) + minNumSegments
+	// each bot starts with 8 segement genes, duplications and deletions change that later
+	segGenes := make([]SegmentGene, initialGenomeLength)
+	// each segment gene have 9 traits
+	for i := range segGenes {
+		segGenes[i] = make(SegmentGene, 9)
+		redInt := r.Intn(256)
+		greenInt := r.Intn(256)
+		blueInt := r.Intn(256)
 
 		segGenes[i][0] = float64(redInt)
 		segGenes[i][1] = float64(greenInt)
 		segGenes[i][2] = float64(blueInt)
 
 		// angleToParent
-		segGenes[i][3] = rand.Float64()*math.Pi - (math.Pi / 2.0) // should take on values from -(pi)/2 to +(pi)/2 -- using radians b/c trig functions take that
+		segGenes[i][3] = r.Float64()*(maxAngleToParent-minAngleToParent) + minAngleToParent // should take on values from -(pi)/2 to +(pi)/2 -- using radians b/c trig functions take that
 		// length
-		segGenes[i][4] = (rand.Float64() * 15.0) + 5.0 // should take on values from 5.0 to 20.0
+		segGenes[i][4] = (r.Float64() * (maxSegmentLength - minSegmentLength)) + minSegmentLength // should take on values from 5.0 to 20.0
 		// width
-		segGenes[i][5] = (rand.Float64() * 3.7) + 0.3 // should take on values from 0.3 to 4.0
+		segGenes[i][5] = (r.Float64() * (maxSegmentWidth - minSegmentWidth)) + minSegmentWidth // should take on values from 0.3 to 4.0
+		// oscillation amplitude and phase, only used by the morphology locomotion
+		segGenes[i][6] = r.Float64() * maxOscillationAmplitude
+		segGenes[i][7] = r.Float64() * maxOscillationPhase
+		// attachment, which segment this one is attached to
+		segGenes[i][8] = r.Float64() * maxAttachment
 	}
 	return common, segGenes
 }
 
 // SetGoal takes a pond and sets a bot's goal for the current timestep based on its goal from the prior timestep and its current energy/state.
-func (newPond *Pond) SetGoal(i int, oldPond *Pond, viewRange float64, hungerThreshold float64, matingPreference int) {
+// r is the bot's random stream for this generation, used if it has to pick a new mate.
+func (newPond *Pond) SetGoal(i int, oldPond *Pond, viewRange float64, hungerThreshold float64, preference MatePreference, r *rand.Rand) {
 	// in many cases, bot should simply keep the same goal it had from the prior timestep
 	needsNewGoal := false
 	bot := newPond.swimbots[i]
 	// check cases in which bot should have its goal updated, changing needsNewGoal to true if applicable
-	if bot.goal.index == -1 {
+	if bot.goal.id == -1 {
 		// this occurs if an execution of FindNewGoal() function from prior timestep fails to find appropriate goal within bot's view range
 		needsNewGoal = true
 	} else {
 		// if the bot's prior goal doesn't match its current state, it will need to find a new goal
-		if (bot.goal.isBot && bot.energy < hungerThreshold) || (!bot.goal.isBot && bot.energy >= hungerThreshold) {
+		// a hunting bot is hungry as well
+		courting := bot.goal.isBot && !bot.goal.isPrey
+		if (courting && bot.energy < hungerThreshold) || (!courting && bot.energy >= hungerThreshold) {
 			needsNewGoal = true
 		}
-		// if the food bit/bot that is its goal no longer exists or has moved out of view, it will also need a new goal
+		// if the food bit/bot that is its goal no longer exists or has moved out of view or behind an obstacle, it will also need a new goal
 		// is the goal a bot?
 		if bot.goal.isBot {
-			currentGoalMate := oldPond.swimbots[bot.goal.index]
-			if currentGoalMate == nil || bot.GoalDistance(oldPond) > viewRange {
+			currentGoalMate := oldPond.SwimbotByID(bot.goal.id)
+			if currentGoalMate == nil || bot.GoalDistance(oldPond) > viewRange || !oldPond.LineOfSight(bot.position, currentGoalMate.position) {
 				needsNewGoal = true
 			}
 		} else {
 			// goal is a food bit
-			currentGoalFood := newPond.foodBits[bot.goal.index]
+			currentGoalFood := newPond.FoodByID(bot.goal.id)
 			// find the new goal if the food is gone or out of range
-			if currentGoalFood == nil || bot.GoalDistance(oldPond) > viewRange {
+			if currentGoalFood == nil || bot.GoalDistance(oldPond) > viewRange || !oldPond.LineOfSight(bot.position, currentGoalFood.position) {
 				needsNewGoal = true
 			}
 		}
@@ -488,117 +696,85 @@ func (newPond *Pond) SetGoal(i int, oldPond *Pond, viewRange float64, hungerThre
 	}
 	// if any of the prior conditions were triggered, bot will update its goal; otherwise it simply retains the prior one
 	if needsNewGoal {
-		bot.goal = bot.FindNewGoal(oldPond, viewRange, hungerThreshold, matingPreference)
+		bot.goal = bot.FindNewGoal(oldPond, viewRange, hungerThreshold, preference, r)
 	}
 }
 
-// FindNewGoal takes a pond and returns a new goal for the swimbot based on its current state/energy. It returns a goal with index field equal to -1 if no objects of the appropriate type are currently within the bot's view range.
-func (bot *Swimbot) FindNewGoal(pond *Pond, viewRange, hungerThreshold float64, matingPreference int) Goal {
+// FindNewGoal takes a pond and returns a new goal for the swimbot based on its current state/energy. It returns a goal with id field equal to -1 if no objects of the appropriate type are currently within the bot's view range.
+// Random mate choices are drawn from r.
+func (bot *Swimbot) FindNewGoal(pond *Pond, viewRange, hungerThreshold float64, preference MatePreference, r *rand.Rand) Goal {
 	var newGoal Goal
-	// bot is hungry, will pursue its closest food bit
-	if bot.energy < hungerThreshold {
+	// a hungry predator hunts the closest bot instead
+	if bot.energy < hungerThreshold && bot.IsPredator() {
+		newGoal.isBot = true
+		newGoal.isPrey = true
+		newGoal.id = bot.FindPrey(pond, viewRange)
+	} else if bot.energy < hungerThreshold { // bot is hungry, will pursue its closest food bit
 		newGoal.isBot = false
 		// will be updated if any foodbits within view are found
 		closestFoodIndex := -1
 
 		var shortestDist float64
 
-		// range through all food bits in pond, keeping track of which is closest (within bot's view)
-		for i := range pond.foodBits {
-			if pond.foodBits[i] != nil {
-				dist := bot.DistanceToFood(pond.foodBits[i])
+		// range through the food bits near the bot, keeping track of which is closest (within bot's view)
+		for _, i := range pond.FoodNear(bot.position, viewRange) {
+			// a bot doesn't go for food it can't eat
+			if pond.foodBits[i] != nil && pond.foodBits[i].EdibleBy(bot) {
+				dist := bot.DistanceToFood(pond.foodBits[i], pond)
 				// if the food is closer update the index and distance
-				if dist < viewRange && (closestFoodIndex == -1 || dist < shortestDist) {
+				if dist < viewRange && (closestFoodIndex == -1 || dist < shortestDist) && pond.LineOfSight(bot.position, pond.foodBits[i].position) {
 					closestFoodIndex = i
 					shortestDist = dist
 				}
 			}
 		}
 		// if no food bits were found within bot's view, this will still hold a value of -1
-		newGoal.index = closestFoodIndex
+		if closestFoodIndex == -1 {
+			newGoal.id = -1
+		} else {
+			newGoal.id = pond.foodBits[closestFoodIndex].id
+		}
 
 	} else { // bot is not hungry, will pursue another bot that is within view and not part of its family
 		newGoal.isBot = true
 
-		suitableBotIndices := make([]int, 0)
-
-		for i := range pond.swimbots {
-			// if it's not himself and not nil
-			if pond.swimbots[i] != bot && pond.swimbots[i] != nil {
-				potentialMate := pond.swimbots[i]
-				// choose bots that is not related to the current bot
-				if bot.DistanceToSwimbot(potentialMate) <= viewRange && !bot.RelatedTo(i) {
-					suitableBotIndices = append(suitableBotIndices, i)
-				}
-			}
-		}
-		// fmt.Println("bot's potentialMate", suitableBotIndices)
-		if len(suitableBotIndices) == 0 {
-			newGoal.index = -1
+		candidates := bot.SuitableMates(pond, viewRange)
+		if len(candidates) == 0 {
+			newGoal.id = -1
 		} else {
-			// choose the mate based on mating preferences
-			// 0: choose a mate randomly
-			// 1: choose swimbot with more segments.
-			// 2: choose swimbot with less segments.
-			// 3: choose swimbot that's faster
-			// 4: similar number of segments.
-			// 5: similar length of mainSegment
-
-			// first set the goal to the first in the potential mating list
-			newGoal.index = suitableBotIndices[0]
-			// based on the matingPreferences, we choose the mate differently
-			if matingPreference == 0 {
-				newGoal.index = suitableBotIndices[rand.Intn(len(suitableBotIndices))]
-			} else if matingPreference == 1 {
-				for i := range suitableBotIndices {
-					if pond.swimbots[suitableBotIndices[i]].botGene.numSegments >= pond.swimbots[newGoal.index].botGene.numSegments {
-						newGoal.index = suitableBotIndices[i]
-					}
-				}
-			} else if matingPreference == 2 {
-				for i := range suitableBotIndices {
-					if pond.swimbots[suitableBotIndices[i]].botGene.numSegments <= pond.swimbots[newGoal.index].botGene.numSegments {
-						newGoal.index = suitableBotIndices[i]
-					}
-				}
-			} else if matingPreference == 3 {
-				for i := range suitableBotIndices {
-					// if the target bot is faster, we update the goal
-					if pond.swimbots[suitableBotIndices[i]].botGene.translationalMovement >= pond.swimbots[newGoal.index].botGene.translationalMovement {
-						newGoal.index = suitableBotIndices[i]
-					}
-				}
-			} else if matingPreference == 4 {
-				newGoal.index = suitableBotIndices[0]
-				// calculate the difference between the mainsegment of the current swimbot and the target swimbot
-				segmentDiff := math.Abs(float64(pond.swimbots[newGoal.index].botGene.numSegments - bot.botGene.numSegments))
-				// range through all the suitable bots
-				for i := 1; i < len(suitableBotIndices); i++ {
-					// if the new segment difference is smaller than the current one, we replace the target with new index
-					if segmentDiff >= math.Abs(float64(pond.swimbots[suitableBotIndices[i]].botGene.numSegments - bot.botGene.numSegments)) {
-						newGoal.index = suitableBotIndices[i]
-						segmentDiff = math.Abs(float64(pond.swimbots[suitableBotIndices[i]].botGene.numSegments - bot.botGene.numSegments))
-					}
-				}
-			} else if matingPreference == 5 {
-				newGoal.index = suitableBotIndices[0]
-				lengthDiff := math.Abs(float64(pond.swimbots[newGoal.index].segGenes[0][4] - bot.segGenes[0][4]))
-				for i := 1; i < len(suitableBotIndices); i++ {
-					// if the difference in length is smaller, we replace it with the new one
-					if lengthDiff >= math.Abs(float64(pond.swimbots[suitableBotIndices[i]].segGenes[0][4] - bot.segGenes[0][4])) {
-						newGoal.index = suitableBotIndices[i]
-						lengthDiff = math.Abs(float64(pond.swimbots[suitableBotIndices[i]].segGenes[0][4] - bot.segGenes[0][4]))
-					}
-				}
-			}
+			// let the mating preference pick one of the suitable bots
+			newGoal.id = candidates[ChooseMate(preference, bot, candidates, pond, r)].id
 		}
 	}
 
 	return newGoal
 }
 
-// DistanceToSwimbot takes a pointer to a swimbot and returns the distance between that swimbot and the one calling the function.
-func (bot *Swimbot) DistanceToSwimbot(otherBot *Swimbot) float64 {
+// SuitableMates returns the bots within the bot's view that it could mate with:
+// not itself, not related to it, not one that rejected it before, not hidden behind an obstacle and not of another species
+func (bot *Swimbot) SuitableMates(pond *Pond, viewRange float64) []*Swimbot {
+	candidates := make([]*Swimbot, 0)
+	for _, i := range pond.SwimbotsNear(bot.position, viewRange) {
+		// if it's not himself and not nil
+		if pond.swimbots[i] != bot && pond.swimbots[i] != nil {
+			potentialMate := pond.swimbots[i]
+			// choose bots that is not related to the current bot
+			if bot.DistanceToSwimbot(potentialMate, pond) <= viewRange && !bot.RelatedTo(potentialMate.id) && !bot.RejectedBy(potentialMate.id) && pond.LineOfSight(bot.position, potentialMate.position) && bot.CompatibleWith(potentialMate, pond) {
+				candidates = append(candidates, potentialMate)
+			}
+		}
+	}
+	return candidates
+}
+
+// RejectedBy returns true if the bot with the given ID rejected the bot and the bot still remembers it
+func (bot *Swimbot) RejectedBy(botID int) bool {
+	_, rejected := bot.rejectedBy[botID]
+	return rejected
+}
+
+// DistanceToSwimbot takes a pointer to a swimbot and a pond and returns the distance between that swimbot and the one calling the function in the pond.
+func (bot *Swimbot) DistanceToSwimbot(otherBot *Swimbot, pond *Pond) float64 {
 	// handle the case if the bot or its goal's distance is not a number
 	if math.IsNaN(bot.position.x) {
 		fmt.Println(bot)
@@ -608,29 +784,20 @@ func (bot *Swimbot) DistanceToSwimbot(otherBot *Swimbot) float64 {
 		fmt.Println(otherBot)
 		panic("Other Bot position is NaN")
 	}
-	// calculate the distance
-	deltaX := otherBot.position.x - bot.position.x
-	deltaY := otherBot.position.y - bot.position.y
-	distance := math.Sqrt((deltaX * deltaX) + (deltaY * deltaY))
-
-	return distance
+	// calculate the distance, the pond decides whether it can go across the edge
+	return pond.Distance(bot.position, otherBot.position)
 }
 
-// DistanceToFood takes a pointer to a food bit and returns the distance between the food bit and the swimbot caling the function.
-func (bot *Swimbot) DistanceToFood(foodBit *Food) float64 {
-	deltaX := foodBit.position.x - bot.position.x
-	deltaY := foodBit.position.y - bot.position.y
-
-	return math.Sqrt((deltaX * deltaX) + (deltaY * deltaY))
+// DistanceToFood takes a pointer to a food bit and a pond and returns the distance between the food bit and the swimbot caling the function in the pond.
+func (bot *Swimbot) DistanceToFood(foodBit *Food, pond *Pond) float64 {
+	return pond.Distance(bot.position, foodBit.position)
 }
 
-// RelatedTo takes a pointer to a swimbot and returns whether it is part of the family of the swimbot calling the function.
-func (bot *Swimbot) RelatedTo(botIndex int) bool {
+// RelatedTo takes the ID of a swimbot and returns whether it is part of the family of the swimbot calling the function.
+func (bot *Swimbot) RelatedTo(botID int) bool {
 	for i := range bot.family {
-		// fmt.Println("bot's family, otherbot")
-		// fmt.Println(bot.family[i], botIndex)
-		// if the index is the same then they are related
-		if bot.family[i] == botIndex {
+		// if the ID is the same then they are related
+		if bot.family[i] == botID {
 			return true
 		}
 	}
@@ -642,13 +809,13 @@ func (bot *Swimbot) GoalDistance(p *Pond) float64 {
 	var dist float64
 
 	if bot.goal.isBot {
-		dist = bot.DistanceToSwimbot(p.swimbots[bot.goal.index])
+		dist = bot.DistanceToSwimbot(p.SwimbotByID(bot.goal.id), p)
 		// handle the case if the distance is not a number
 		if math.IsNaN(dist) {
 			fmt.Println("Distance to swimbot is NaN")
 		}
 	} else {
-		dist = bot.DistanceToFood(p.foodBits[bot.goal.index])
+		dist = bot.DistanceToFood(p.FoodByID(bot.goal.id), p)
 		// handle the case if the distance is not a number
 		if math.IsNaN(dist) {
 			fmt.Println("Distance to food is NaN")
@@ -687,16 +854,9 @@ func (SegNew *Segment) CopySegment(seg *Segment) {
 	SegNew.index = seg.index
 }
 
-// AddFood add new food to the pond
-func (pond *Pond) AddFood(numGen int, numFood int, foodFrequency int) {
-	if numGen%foodFrequency == 0 { // we are at a timestep that is a multiple of 20, add food at this step
-		for i := 0; i < numFood; i++ { // add food bits at random positions on the board
-			var f Food
-			f.position.x = float64(rand.Intn(5000) + 500)
-			f.position.y = float64(rand.Intn(5000) + 500)
-			pond.foodBits = append(pond.foodBits, &f)
-		}
-	}
+// AddFood add new food to the pond, where and how much is up to the food model of the config
+func (pond *Pond) AddFood(numGen int, config *SimulationConfig) {
+	config.FoodModel().AddFood(pond, numGen, pond.rng.food)
 }
 
 // CopyPond copy all the fields in the pond to a new pond
@@ -704,6 +864,18 @@ func CopyPond(oldPond *Pond) *Pond {
 	var newPond Pond
 
 	newPond.width = oldPond.width
+	newPond.height = oldPond.height
+	newPond.boundary = oldPond.boundary
+	newPond.obstacles = oldPond.obstacles
+	newPond.foodTypes = oldPond.foodTypes
+	newPond.speciesThreshold = oldPond.speciesThreshold
+	// the random streams belong to the whole run, not to a single time point
+	newPond.rng = oldPond.rng
+	newPond.pedigree = oldPond.pedigree
+	newPond.nextBotID = oldPond.nextBotID
+	newPond.nextFoodID = oldPond.nextFoodID
+	newPond.botSlots = CopySlots(oldPond.botSlots)
+	newPond.foodSlots = CopySlots(oldPond.foodSlots)
 	numBots := len(oldPond.swimbots)
 	newPond.swimbots = make([]*Swimbot, numBots)
 
@@ -713,6 +885,7 @@ func CopyPond(oldPond *Pond) *Pond {
 		} else {
 			// copy every field in at the bottom
 			var SwimbotNew Swimbot
+			SwimbotNew.id = oldPond.swimbots[i].id
 			SwimbotNew.goal = oldPond.swimbots[i].goal
 			SwimbotNew.age = oldPond.swimbots[i].age
 			SwimbotNew.energy = oldPond.swimbots[i].energy
@@ -724,27 +897,40 @@ func CopyPond(oldPond *Pond) *Pond {
 			SwimbotNew.acceleration.y = oldPond.swimbots[i].acceleration.y
 			SwimbotNew.mass = oldPond.swimbots[i].mass
 
-			// CHECK FAMILY POINTERS!!!!!!!!!!!!!!!
+			// copy the family so a new child doesn't change the family in the old pond
 			fam := make([]int, len(oldPond.swimbots[i].family))
-			fam = oldPond.swimbots[i].family
+			copy(fam, oldPond.swimbots[i].family)
 			SwimbotNew.family = fam
+			SwimbotNew.rejections = oldPond.swimbots[i].rejections
+			if oldPond.swimbots[i].rejectedBy != nil {
+				SwimbotNew.rejectedBy = make(map[int]float64, len(oldPond.swimbots[i].rejectedBy))
+				for botID, age := range oldPond.swimbots[i].rejectedBy {
+					SwimbotNew.rejectedBy[botID] = age
+				}
+			}
 
 			var newCommonGene CommonGene
 			newCommonGene.angularMovement = oldPond.swimbots[i].botGene.angularMovement
 			newCommonGene.translationalMovement = oldPond.swimbots[i].botGene.translationalMovement
 			newCommonGene.numSegments = oldPond.swimbots[i].botGene.numSegments
+			newCommonGene.diet = oldPond.swimbots[i].botGene.diet
+			newCommonGene.viewRange = oldPond.swimbots[i].botGene.viewRange
+			newCommonGene.hungerThreshold = oldPond.swimbots[i].botGene.hungerThreshold
+			newCommonGene.preference = oldPond.swimbots[i].botGene.preference
 			SwimbotNew.botGene = newCommonGene
 
-			segGenesNew := make([]SegmentGene, 8)
+			segGenesNew := make([]SegmentGene, len(oldPond.swimbots[i].segGenes))
 			for k := range segGenesNew {
-				segGenesNew[k] = make(SegmentGene, 6)
-				for j := 0; j < 6; j++ {
+				segGenesNew[k] = make(SegmentGene, len(oldPond.swimbots[i].segGenes[k]))
+				for j := range segGenesNew[k] {
 
 					segGenesNew[k][j] = oldPond.swimbots[i].segGenes[k][j]
 				}
 			}
 
 			SwimbotNew.segGenes = segGenesNew
+			SwimbotNew.brainGenes = append([]float64(nil), oldPond.swimbots[i].brainGenes...)
+			SwimbotNew.haplotypes = CopyHaplotypes(oldPond.swimbots[i].haplotypes)
 			// We need to copy the subsegments recursively
 			SwimbotNew.mainSegment = CopySegmentTree(*oldPond.swimbots[i].mainSegment)
 			newPond.swimbots[i] = &SwimbotNew
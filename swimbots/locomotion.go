@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math"
+)
+
+// The locomotion modes. With "genes" a bot swims at the speed of its translationalMovement gene
+// and turns at most its angularMovement gene per generation, its body is only drawn.
+// With "morphology" the speed and the turning come from the body itself, see Locomotion.
+const (
+	genesLocomotion      = "genes"
+	morphologyLocomotion = "morphology"
+)
+
+// strokePeriod is the number of generations a segment takes for one full oscillation
+const strokePeriod = 20.0
+
+// Locomotion returns how fast the bot swims and how far it can turn in the current generation.
+// Every segment attached to the main segment oscillates around the angle it was built with,
+// pushing the water with a strength proportional to its length, width and oscillation amplitude.
+// A segment lying across the swimming direction pushes the bot forward, while a segment lying
+// along the swimming direction sweeps sideways and steers the bot, like a tail. The push of each
+// segment rises and falls with its phase, and the water drags on the whole area of the body,
+// so a big body needs strong strokes to be fast.
+func (bot *Swimbot) Locomotion(config *SimulationConfig) (speed, turning float64) {
+	if config.locomotion != morphologyLocomotion {
+		return bot.botGene.translationalMovement, bot.botGene.angularMovement
+	}
+
+	segments := bot.mainSegment.CollectSegments(make([]*Segment, 0))
+	var thrust, steering, area float64
+	for _, seg := range segments {
+		gene := bot.segGenes[seg.index]
+		area += gene[4] * gene[5]
+		if seg == bot.mainSegment {
+			// the main segment carries the others, it doesn't oscillate
+			continue
+		}
+		stroke := gene[4] * gene[5] * gene[6]
+		// the angle of the segment relative to the main segment, the swimming direction
+		relativeAngle := seg.angle - bot.mainSegment.angle
+		pulse := 1 + math.Sin(2*math.Pi*bot.age/strokePeriod+gene[7])
+		thrust += stroke * math.Abs(math.Sin(relativeAngle)) * pulse
+		steering += stroke * math.Abs(math.Cos(relativeAngle))
+	}
+
+	speed = Clamp(config.thrustFactor*thrust/area, minTranslationalMovement, maxTranslationalMovement)
+	turning = Clamp(config.turnFactor*steering/area, minAngularMovement, maxAngularMovement)
+	return speed, turning
+}
+
+// CollectSegments appends the segment and every segment attached to it, directly or not, to segments
+func (seg *Segment) CollectSegments(segments []*Segment) []*Segment {
+	segments = append(segments, seg)
+	for _, sub := range seg.subSegments {
+		segments = sub.CollectSegments(segments)
+	}
+	return segments
+}
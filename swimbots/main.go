@@ -3,31 +3,15 @@ package main
 import (
 	"fmt"
 	"gifhelper"
-	"math/rand"
+	"runtime"
 )
 
 func main() {
 
-	rand.Seed(0)
-
 	var isDefault string
 
-	// basic
-	var numGen int
-	var time float64
-
-	// parameters
-	var numInitialBots int
-	var numFood int
-	var viewRange float64
-	var proximity float64
-	var foodEnergy float64
-	var hungerThreshold float64
-	var maximumAge float64
-	var foodFrequency int
-	var segmentMass float64
-	var energyLossFactor float64
-	var matingPreference int
+	// start from the default parameters and overwrite them with the user's input if needed
+	config := NewSimulationConfig()
 
 	fmt.Println("Welcome to swimbot genepool simulation.")
 	fmt.Println("Would you like to simulate the genepool with default parameters? (y/n)")
@@ -36,44 +20,43 @@ func main() {
 	if isDefault == "y" {
 		fmt.Println("Simulating genepool with default parameters.")
 
-		numGen = 1000
-		fmt.Println("Number of generations: ", numGen)
+		fmt.Println("Number of generations: ", config.numGens)
+
+		fmt.Println("Time interval: ", config.time)
+
+		fmt.Println("Initial number of bots: ", config.numInitialBots)
+
+		fmt.Println("Number of food bits we add every time we add food: ", config.numFood)
 
-		time = 1
-		fmt.Println("Time interval: ", time)
+		fmt.Println("View range of a swimbot: ", config.viewRange)
 
-		numInitialBots = 200
-		fmt.Println("Initial number of bots: ", numInitialBots)
+		fmt.Println("Proximity for swimbot to eat or mate:", config.proximity)
 
-		numFood = 5
-		fmt.Println("Number of food bits we add every time we add food: ", numFood)
+		fmt.Println("Energy of the foodBits", config.foodEnergy)
 
-		viewRange = 300
-		fmt.Println("View range of a swimbot: ", viewRange)
+		fmt.Println("Energy threshold for hungry: ", config.hungerThreshold)
 
-		proximity = 10
-		fmt.Println("Proximity for swimbot to eat or mate:", proximity)
+		fmt.Println("Maximum age of a bot: ", config.maximumAge)
 
-		foodEnergy = 50
-		fmt.Println("Energy of the foodBits", foodEnergy)
+		fmt.Println("The frequency of putting in food: ", config.foodFrequency)
 
-		hungerThreshold = 50
-		fmt.Println("Energy threshold for hungry: ", hungerThreshold)
+		fmt.Println("The mass of each segment:", config.segmentMass)
 
-		maximumAge = 1000
-		fmt.Println("Maximum age of a bot: ", maximumAge)
+		fmt.Println("The energy loss factor is:", config.energyLossFactor)
 
-		foodFrequency = 5
-		fmt.Println("The frequency of putting in food: ", 5)
+		fmt.Println("The mating preference is:", config.matingPreference)
 
-		segmentMass = 10.0
-		fmt.Println("The mass of each segment:", segmentMass)
+		fmt.Println("The locomotion is:", config.locomotion)
 
-		energyLossFactor = 0.0005
-		fmt.Println("The energy loss factor is:", energyLossFactor)
+		fmt.Println("The size of the pond is:", config.width, "x", config.height)
 
-		matingPreference = 0
-		fmt.Println("The mating prefernce is randomized.")
+		fmt.Println("The boundary of the pond is:", config.boundary)
+
+		fmt.Println("The random seed is:", config.seed)
+
+		fmt.Println("Number of workers:", config.numWorkers)
+
+		fmt.Println("The compaction interval is:", config.compactionInterval)
 
 	} else if isDefault == "n" {
 
@@ -82,96 +65,370 @@ func main() {
 		// numGen
 		fmt.Println("How many generations would you like to simulate?")
 		fmt.Println("Please input a integer. (The default value is 1000)")
-		fmt.Scan(&numGen)
+		fmt.Scan(&config.numGens)
 
 		// time
 		fmt.Println("What's the time interval for each gneration?")
 		fmt.Println("Please input a float64. (The default value is 1.0)")
-		fmt.Scan(&time)
+		fmt.Scan(&config.time)
 
 		// numInitialBots
 		fmt.Println("How many swimbots would you like to have in the initial pond?")
 		fmt.Println("Please input a integer. (The default value is 200)")
-		fmt.Scan(&numInitialBots)
+		fmt.Scan(&config.numInitialBots)
 
 		// numFood
 		fmt.Println("How many food bits do you want to add everytime?")
 		fmt.Println("Please input a integer. (The default value is 5)")
-		fmt.Scan(&numFood)
+		fmt.Scan(&config.numFood)
 
 		// viewRange
 		fmt.Println("How far do you want a swimbot to see to pick it's goal to swim towards?")
 		fmt.Println("Please input a float64. (The default value is 300)")
-		fmt.Scan(&viewRange)
+		fmt.Scan(&config.viewRange)
 
 		// proximity
 		fmt.Println("How close does a swimbot have to get to its goal in order to eat or mate?")
 		fmt.Println("Please input a float64. (The default value is 10)")
-		fmt.Scan(&proximity)
+		fmt.Scan(&config.proximity)
 
 		// foodEnergy
 		fmt.Println("How much energy does a swimbot gain when eating a food?")
 		fmt.Println("Please input a float64. (The default value is 50.0)")
-		fmt.Scan(&foodEnergy)
+		fmt.Scan(&config.foodEnergy)
 
 		// hungerThreshold
 		fmt.Println("What's the hunger threshold of the swimbot?")
 		fmt.Println("Please input a float64. (The default value is 50)")
-		fmt.Scan(&hungerThreshold)
+		fmt.Scan(&config.hungerThreshold)
 
 		// hungerThreshold
 		fmt.Println("What's the maximum age of a swimbot?")
 		fmt.Println("Please input a float64. (The default value is 1000)")
-		fmt.Scan(&maximumAge)
+		fmt.Scan(&config.maximumAge)
 
 		// foodFrequency
 		fmt.Println("How often do you want to throw food into the pond?")
 		fmt.Println("Please input a int. (The default value is 5)")
-		fmt.Scan(&foodFrequency)
+		fmt.Scan(&config.foodFrequency)
 
 		// foodFrequency
 		fmt.Println("What's the basic value for mass of a segment in swimbots?")
 		fmt.Println("Please input a float64. (The default value is 10.0)")
-		fmt.Scan(&segmentMass)
+		fmt.Scan(&config.segmentMass)
 
 		// energyLossFactor
 		fmt.Println("How much energy was loss when the swimbot swim?")
 		fmt.Println("Please input a float64. (The default value is 0.0005)")
-		fmt.Scan(&energyLossFactor)
+		fmt.Scan(&config.energyLossFactor)
+
+		// energy model
+		fmt.Println("How is the mass of a swimbot computed?")
+		fmt.Println("segments: every segment has the mass of a segment.")
+		fmt.Println("area: the mass grows with the area of the segments, an average segment has the mass of a segment.")
+		fmt.Println("Please input segments or area. (The default value is segments)")
+		fmt.Scan(&config.massModel)
+		fmt.Println("How much energy per unit of mass does a swimbot burn every generation just to stay alive?")
+		fmt.Println("Please input a float64. (The default value is 0)")
+		fmt.Scan(&config.basalMetabolism)
+		fmt.Println("How much energy per unit of mass and radian does a swimbot lose when it turns?")
+		fmt.Println("Please input a float64. (The default value is 0)")
+		fmt.Scan(&config.turningCost)
+		fmt.Println("How much energy does each parent lose when a child is born, on top of the half it gives the child?")
+		fmt.Println("Please input a float64. (The default value is 0)")
+		fmt.Scan(&config.reproductionCost)
 
 		// matingPreference
 		fmt.Println("How should the swimbots pick their mate?")
-		fmt.Println("0: randomly choose a mate.")
-		fmt.Println("1: choose mate with more segments.")
-		fmt.Println("2: choose mate with less segments.")
-		fmt.Println("3: choose mate that's faster.")
-		fmt.Println("4: choose mate that have similar number of segments.")
-		fmt.Println("5: choose mate with similar main segment length.")
+		PrintMatePreferences()
+		fmt.Println("Please input the name of a preference. (The default value is random)")
+		fmt.Scan(&config.matingPreference)
+
+		// mutualChoice
+		fmt.Println("Can a swimbot reject the swimbot that courts it? It then judges the suitor with its own mating preference.")
+		fmt.Println("Please input true or false. (The default value is false)")
+		fmt.Scan(&config.mutualChoice)
+		if config.mutualChoice {
+			fmt.Println("Which fraction of the other possible mates may be better than the suitor for it to be accepted?")
+			fmt.Println("Please input a float64 between 0 and 1. (The default value is 0.5)")
+			fmt.Scan(&config.acceptFraction)
+			fmt.Println("How much energy does a swimbot lose when it gets rejected?")
+			fmt.Println("Please input a float64. (The default value is 5)")
+			fmt.Scan(&config.rejectionCost)
+		}
+
+		// mutations
+		var mutations bool
+		fmt.Println("Do the genes of a child mutate? Without mutations a child only recombines the genes of its parents.")
+		fmt.Println("Please input true or false. (The default value is false)")
+		fmt.Scan(&mutations)
+		if mutations {
+			config.mutation.EnableMutations()
+		}
+
+		// behaviour genes
+		fmt.Println("Does every swimbot inherit its own view range, hunger threshold and mating preference?")
+		fmt.Println("The values above then only set the genes of the initial swimbots, and the genes mutate like the others.")
+		fmt.Println("Please input true or false. (The default value is false)")
+		fmt.Scan(&config.behaviourGenes)
+		if config.behaviourGenes {
+			fmt.Println("How far do the initial view ranges and hunger thresholds lie from the values above, as a fraction of them?")
+			fmt.Println("The same fraction of the initial swimbots gets a random mating preference.")
+			fmt.Println("Please input a float64 between 0 and 1. (The default value is 0.2)")
+			fmt.Scan(&config.behaviourSpread)
+		}
+
+		// predation
+		fmt.Println("Can swimbots hunt other swimbots? Swimbots with a high diet gene then eat other swimbots instead of food.")
+		fmt.Println("Please input true or false. (The default value is false)")
+		fmt.Scan(&config.predation)
+		if config.predation {
+			fmt.Println("Which fraction of the initial swimbots are predators?")
+			fmt.Println("Please input a float64 between 0 and 1. (The default value is 0.1)")
+			fmt.Scan(&config.initialPredators)
+			fmt.Println("Which fraction of the energy of its prey does a predator gain?")
+			fmt.Println("Please input a float64. (The default value is 0.5)")
+			fmt.Scan(&config.predationEfficiency)
+			fmt.Println("Which trait decides an attack? The stronger swimbot wins, the prey also escapes a predator that is as strong as it.")
+			fmt.Println("size: the area of the body. speed: how fast the swimbot swims. segments: the number of segments.")
+			fmt.Println("Please input size, speed or segments. (The default value is size)")
+			fmt.Scan(&config.attackTrait)
+			fmt.Println("How much energy does a predator lose when its prey escapes?")
+			fmt.Println("Please input a float64. (The default value is 5)")
+			fmt.Scan(&config.attackCost)
+		}
+
+		// species
+		fmt.Println("How many species does the initial pond hold? Every species descends from its own random founder.")
+		fmt.Println("Please input a integer. (The default value is 1)")
+		fmt.Scan(&config.numSpecies)
+		if config.numSpecies > 1 {
+			fmt.Println("How far do the initial swimbots lie from their founder, as a fraction of the range of every trait?")
+			fmt.Println("Please input a float64. (The default value is 0.05)")
+			fmt.Scan(&config.founderSpread)
+		}
+		fmt.Println("Above which genetic distance can two swimbots not mate anymore? The distance lies between 0 and 1.")
+		fmt.Println("Please input a float64, or 0 to let every swimbot mate with every other. (The default value is 0)")
+		fmt.Scan(&config.speciesThreshold)
+
+		// diploid
+		fmt.Println("Does every swimbot carry two copies of its genome? Each parent then passes on one copy, and the traits follow the rules of dominance.")
+		fmt.Println("Please input true or false. (The default value is false)")
+		fmt.Scan(&config.diploid)
+		if config.diploid {
+			fmt.Println("How is the colour of a swimbot with two different colour alleles expressed?")
+			fmt.Println("dominant: the brighter allele shows. recessive: the darker allele shows. codominant: the average of both shows.")
+			fmt.Println("Please input dominant, recessive or codominant. (The default value is dominant)")
+			fmt.Scan(&config.dominance.color)
+		}
+
+		// locomotion
+		fmt.Println("How should the swimbots swim?")
+		fmt.Println("genes: the speed and the turning come from the translational and angular movement genes.")
+		fmt.Println("morphology: the speed and the turning come from the shape of the body and the strokes of its segments.")
+		fmt.Println("Please input genes or morphology. (The default value is genes)")
+		fmt.Scan(&config.locomotion)
+
+		// steering
+		fmt.Println("How should the swimbots steer?")
+		fmt.Println("rule: a swimbot turns toward its goal as far as it can and swims at full speed.")
+		fmt.Println("neural: a small neural network in the genome decides the turn and the speed from the closest food, the closest mate, the energy and the age.")
+		fmt.Println("Please input rule or neural. (The default value is rule)")
+		fmt.Scan(&config.steering)
+
+		// width and height
+		fmt.Println("How wide is the pond?")
+		fmt.Println("Please input a float64. (The default value is 6000)")
+		fmt.Scan(&config.width)
+		fmt.Println("How high is the pond?")
+		fmt.Println("Please input a float64. (The default value is 6000)")
+		fmt.Scan(&config.height)
+
+		// obstacles
+		fmt.Println("Which file describes the obstacles in the pond?")
+		fmt.Println("Please input a file name, or none for a pond without obstacles. (The default value is none)")
+		var obstacleFile string
+		fmt.Scan(&obstacleFile)
+		if obstacleFile != "none" {
+			obstacles, err := ReadObstacles(obstacleFile)
+			if err != nil {
+				panic(err)
+			}
+			config.obstacles = obstacles
+		}
+
+		// food types
+		fmt.Println("Which file describes the types of food in the pond?")
+		fmt.Println("Please input a file name, or none for plain food that every swimbot can eat. (The default value is none)")
+		var foodTypeFile string
+		fmt.Scan(&foodTypeFile)
+		if foodTypeFile != "none" {
+			foodTypes, err := ReadFoodTypes(foodTypeFile)
+			if err != nil {
+				panic(err)
+			}
+			config.foodTypes = foodTypes
+		}
+
+		// boundary
+		fmt.Println("What happens to a swimbot that reaches the edge of the pond?")
+		fmt.Println("reflect: it bounces back into the pond.")
+		fmt.Println("wrap: it comes back in at the opposite edge, the pond has no edges at all.")
+		fmt.Println("absorb: it dies.")
+		fmt.Println("Please input reflect, wrap or absorb. (The default value is reflect)")
+		fmt.Scan(&config.boundary)
+
+		// seed
+		fmt.Println("Which random seed should the simulation use? Runs with the same seed produce the same pond.")
 		fmt.Println("Please input a integer. (The default value is 0)")
-		fmt.Scan(&matingPreference)
-
-		fmt.Println("Number of generations: ", numGen)
-		fmt.Println("Time interval: ", time)
-		fmt.Println("Initial number of bots: ", numInitialBots)
-		fmt.Println("Number of food bits we add every time we add food: ", numFood)
-		fmt.Println("View range of a swimbot: ", viewRange)
-		fmt.Println("The proximity is:", proximity)
-		fmt.Println("The food energy is:", foodEnergy)
-		fmt.Println("Energy threshold for hungry: ", hungerThreshold)
-		fmt.Println("Maximum age of a bot: ", maximumAge)
-		fmt.Println("The frequency of putting in food: ", foodFrequency)
-		fmt.Println("The mass of each segment: ", segmentMass)
-		fmt.Println("The energy loss factor: ", energyLossFactor)
-		fmt.Println("The mating preference: ", matingPreference)
+		fmt.Scan(&config.seed)
+
+		// numWorkers
+		fmt.Println("How many goroutines should update the swimbots in parallel? The result is the same with any number of them.")
+		fmt.Printf("Please input a integer. (The default value is 1, this computer has %d cores)\n", runtime.NumCPU())
+		fmt.Scan(&config.numWorkers)
+
+		// compactionInterval
+		fmt.Println("How many generations should pass between two compactions of the pond, which throw away the dead swimbots and the eaten food?")
+		fmt.Println("Compaction doesn't change the simulation, it only keeps the pond small. 0 never compacts.")
+		fmt.Println("Please input a integer. (The default value is 50)")
+		fmt.Scan(&config.compactionInterval)
+
+		// food model
+		fmt.Println("How should the food appear in the pond?")
+		fmt.Println("uniform: the number of food bits you chose at random positions, every few generations.")
+		fmt.Println("patches: the same, but clustered around a few random patches.")
+		fmt.Println("logistic: the food regrows in every cell of a grid, fastest in half full cells.")
+		fmt.Println("seasonal: like uniform, but the amount of food rises and falls over the year.")
+		fmt.Println("image: the food appears where a grayscale image stretched over the pond is bright.")
+		fmt.Println("Please input uniform, patches, logistic, seasonal or image. (The default value is uniform)")
+		var foodModel string
+		fmt.Scan(&foodModel)
+		fmt.Println("How many food bits are in the initial pond? For logistic, how many in each cell?")
+		fmt.Println("Please input a integer. (The default value is 400, for logistic 1)")
+		fmt.Scan(&config.initialFood)
+		switch foodModel {
+		case "uniform":
+		case "patches":
+			numPatches := 5
+			radius := 300.0
+			fmt.Println("How many patches are there, and how far does the food spread around them?")
+			fmt.Println("Please input a integer and a float64. (The default values are 5 and 300)")
+			fmt.Scan(&numPatches, &radius)
+			centers := RandomPatchCenters(numPatches, config.width, config.height, config.seed)
+			config.foodModel = NewPatchFood(centers, radius, config.initialFood, config.numFood, config.foodFrequency)
+		case "logistic":
+			cellSize, capacity, growthRate, seedRate := 300.0, 4.0, 0.02, 0.001
+			fmt.Println("What's the size of a cell, the number of food bits it holds, its growth rate and its seed rate?")
+			fmt.Println("Please input four float64. (The default values are 300, 4, 0.02 and 0.001)")
+			fmt.Scan(&cellSize, &capacity, &growthRate, &seedRate)
+			config.foodModel = NewLogisticFood(cellSize, capacity, growthRate, seedRate, config.initialFood)
+		case "seasonal":
+			period, amplitude := 200.0, 0.8
+			fmt.Println("How many generations does a year last, and how strong are the seasons?")
+			fmt.Println("Please input a float64 and a float64 between 0 and 1. (The default values are 200 and 0.8)")
+			fmt.Scan(&period, &amplitude)
+			config.foodModel = NewSeasonalFood(config.initialFood, config.numFood, config.foodFrequency, period, amplitude)
+		case "image":
+			fmt.Println("Which png, jpeg or gif image describes the density of the food?")
+			var imageFile string
+			fmt.Scan(&imageFile)
+			model, err := ReadDensityImage(imageFile, config.initialFood, config.numFood, config.foodFrequency)
+			if err != nil {
+				panic(err)
+			}
+			config.foodModel = model
+		default:
+			panic("Unknown food model " + foodModel)
+		}
+
+		fmt.Println("Number of generations: ", config.numGens)
+		fmt.Println("Time interval: ", config.time)
+		fmt.Println("Initial number of bots: ", config.numInitialBots)
+		fmt.Println("Number of food bits we add every time we add food: ", config.numFood)
+		fmt.Println("View range of a swimbot: ", config.viewRange)
+		fmt.Println("The proximity is:", config.proximity)
+		fmt.Println("The food energy is:", config.foodEnergy)
+		fmt.Println("Energy threshold for hungry: ", config.hungerThreshold)
+		fmt.Println("Maximum age of a bot: ", config.maximumAge)
+		fmt.Println("The frequency of putting in food: ", config.foodFrequency)
+		fmt.Println("The mass of each segment: ", config.segmentMass)
+		fmt.Println("The energy loss factor: ", config.energyLossFactor)
+		fmt.Println("The mass model: ", config.massModel)
+		fmt.Println("The basal metabolism: ", config.basalMetabolism)
+		fmt.Println("The turning cost: ", config.turningCost)
+		fmt.Println("The reproduction cost: ", config.reproductionCost)
+		fmt.Println("The mating preference: ", config.matingPreference)
+		fmt.Println("Mutual mate choice: ", config.mutualChoice)
+		if config.mutualChoice {
+			fmt.Println("The accept fraction: ", config.acceptFraction)
+			fmt.Println("The rejection cost: ", config.rejectionCost)
+		}
+		fmt.Println("Mutations: ", mutations)
+		fmt.Println("Behaviour genes: ", config.behaviourGenes)
+		if config.behaviourGenes {
+			fmt.Println("The behaviour spread: ", config.behaviourSpread)
+		}
+		fmt.Println("Predation: ", config.predation)
+		if config.predation {
+			fmt.Println("The fraction of initial predators: ", config.initialPredators)
+			fmt.Println("The predation efficiency: ", config.predationEfficiency)
+			fmt.Println("The attack trait: ", config.attackTrait)
+			fmt.Println("The attack cost: ", config.attackCost)
+		}
+		fmt.Println("Number of initial species: ", config.numSpecies)
+		if config.numSpecies > 1 {
+			fmt.Println("The founder spread: ", config.founderSpread)
+		}
+		fmt.Println("The species threshold: ", config.speciesThreshold)
+		fmt.Println("Diploid genomes: ", config.diploid)
+		if config.diploid {
+			fmt.Println("The dominance of the colour: ", config.dominance.color)
+		}
+		fmt.Println("Locomotion: ", config.locomotion)
+		fmt.Println("Steering: ", config.steering)
+		fmt.Println("The size of the pond: ", config.width, "x", config.height)
+		fmt.Println("Number of obstacles: ", len(config.obstacles))
+		fmt.Println("Number of food types: ", len(config.foodTypes))
+		fmt.Println("The food model: ", foodModel)
+		fmt.Println("Initial food: ", config.initialFood)
+		fmt.Println("The boundary of the pond: ", config.boundary)
+		fmt.Println("The random seed: ", config.seed)
+		fmt.Println("Number of workers: ", config.numWorkers)
+		fmt.Println("The compaction interval: ", config.compactionInterval)
 
 	} else {
 		panic("Invalid answer!")
 	}
 
+	// make sure the parameters make sense before we start the simulation
+	if err := config.Validate(); err != nil {
+		panic(err)
+	}
+
 	fmt.Println("Parameters received. Start Simulation!")
 
-	timePoints := SimulatePond(numGen, time, numInitialBots, numFood, viewRange, proximity, foodEnergy, hungerThreshold, maximumAge, foodFrequency, segmentMass, energyLossFactor, matingPreference)
-	images := AnimateSystem(timePoints, 2000, 1, 10)
+	// the frames and the analysis are produced while the simulation runs,
+	// so we never keep more than the first and the current pond in memory
+	sim := NewSimulator(config)
+	frames := NewFrameRecorder(2000, 1, 10)
+	var endpoints EndpointRecorder
+	sim.AddObserver(frames)
+	sim.AddObserver(&endpoints)
+	// the species are only tracked when the genetic distance decides who can mate
+	var species *SpeciesTracker
+	if config.speciesThreshold > 0 {
+		species = NewSpeciesTracker(config.speciesThreshold, 10)
+		sim.AddObserver(species)
+	}
+	// the behaviour genes are recorded when every bot has its own
+	var behaviour *BehaviourRecorder
+	if config.behaviourGenes {
+		behaviour = NewBehaviourRecorder(10)
+		sim.AddObserver(behaviour)
+	}
+	sim.Run()
+	images := frames.Images()
 	fmt.Println("Images drawn!")
 
 	// making gif for the simulations
@@ -180,8 +437,28 @@ func main() {
 	fmt.Println("Animated GIF produced!")
 
 	fmt.Println("Analyzing result.")
-	GenerateAnalysis(timePoints[0], timePoints[len(timePoints)-1], numGen)
+	GenerateAnalysis(endpoints.first, endpoints.last, endpoints.lastGen)
 	fmt.Println("txt file produced.")
+
+	// the pedigree holds every bot ever born in the run
+	fmt.Println("Exporting pedigree.")
+	endpoints.last.pedigree.WritePedigreeCSV("csvFiles/pedigree")
+	endpoints.last.pedigree.WritePedigreeDOT("pedigree")
+	fmt.Println("Pedigree exported.")
+
+	if species != nil {
+		fmt.Println("Exporting species.")
+		fmt.Println(species)
+		species.WriteSpeciesCSV("csvFiles/species")
+		fmt.Println("Species exported.")
+	}
+
+	if behaviour != nil {
+		fmt.Println("Exporting behaviour.")
+		behaviour.WriteBehaviourCSV("csvFiles/behaviour")
+		fmt.Println("Behaviour exported.")
+	}
+
 	fmt.Println("Existing normally.")
 
 }
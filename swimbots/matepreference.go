@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// MatePreference decides which mate a swimbot goes for.
+// Score rates a candidate from the point of view of the chooser, and the chooser picks the candidate
+// with the highest score among the suitable bots within its view (see ChooseMate).
+type MatePreference interface {
+	Score(chooser, candidate *Swimbot, pond *Pond, r *rand.Rand) float64
+}
+
+// MatePreferenceFunc lets an ordinary function be used as a MatePreference
+type MatePreferenceFunc func(chooser, candidate *Swimbot, pond *Pond, r *rand.Rand) float64
+
+// Score calls f
+func (f MatePreferenceFunc) Score(chooser, candidate *Swimbot, pond *Pond, r *rand.Rand) float64 {
+	return f(chooser, candidate, pond, r)
+}
+
+// registeredPreference is an entry of the registry of mate preferences
+type registeredPreference struct {
+	name        string
+	description string
+	preference  MatePreference
+}
+
+// matePreferences holds every registered preference in the order it was registered
+var matePreferences []registeredPreference
+
+// RegisterMatePreference makes a preference available under the given name, so it can be picked in the config.
+// It panics if the name is already taken. Register your preferences before the simulation starts.
+func RegisterMatePreference(name, description string, preference MatePreference) {
+	for _, p := range matePreferences {
+		if p.name == name {
+			panic("mate preference " + name + " is registered twice")
+		}
+	}
+	matePreferences = append(matePreferences, registeredPreference{name, description, preference})
+}
+
+// LookupMatePreference returns the preference registered under the given name
+func LookupMatePreference(name string) (MatePreference, error) {
+	for _, p := range matePreferences {
+		if p.name == name {
+			return p.preference, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown mate preference %q", name)
+}
+
+// MatePreferenceIndex returns the position of the preference registered under the given name in MatePreferenceNames,
+// or -1 if there is none
+func MatePreferenceIndex(name string) int {
+	for i, p := range matePreferences {
+		if p.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// MatePreferenceNames returns the names of the registered preferences in the order they were registered
+func MatePreferenceNames() []string {
+	names := make([]string, len(matePreferences))
+	for i, p := range matePreferences {
+		names[i] = p.name
+	}
+	return names
+}
+
+// PrintMatePreferences prints the name and the description of every registered preference
+func PrintMatePreferences() {
+	for _, p := range matePreferences {
+		fmt.Println(p.name + ": " + p.description)
+	}
+}
+
+// ChooseMate returns the position in candidates of the candidate with the highest score.
+// When several candidates share the highest score, the last of them wins.
+func ChooseMate(preference MatePreference, chooser *Swimbot, candidates []*Swimbot, pond *Pond, r *rand.Rand) int {
+	best := 0
+	bestScore := preference.Score(chooser, candidates[0], pond, r)
+	for i := 1; i < len(candidates); i++ {
+		score := preference.Score(chooser, candidates[i], pond, r)
+		if score >= bestScore {
+			best = i
+			bestScore = score
+		}
+	}
+	return best
+}
+
+// the built-in preferences
+func init() {
+	RegisterMatePreference("random", "randomly choose a mate.",
+		MatePreferenceFunc(func(chooser, candidate *Swimbot, pond *Pond, r *rand.Rand) float64 {
+			return r.Float64()
+		}))
+	RegisterMatePreference("more-segments", "choose mate with more segments.",
+		MatePreferenceFunc(func(chooser, candidate *Swimbot, pond *Pond, r *rand.Rand) float64 {
+			return float64(candidate.botGene.numSegments)
+		}))
+	RegisterMatePreference("fewer-segments", "choose mate with less segments.",
+		MatePreferenceFunc(func(chooser, candidate *Swimbot, pond *Pond, r *rand.Rand) float64 {
+			return -float64(candidate.botGene.numSegments)
+		}))
+	RegisterMatePreference("faster", "choose mate that's faster.",
+		MatePreferenceFunc(func(chooser, candidate *Swimbot, pond *Pond, r *rand.Rand) float64 {
+			return candidate.botGene.translationalMovement
+		}))
+	RegisterMatePreference("similar-segments", "choose mate that have similar number of segments.",
+		MatePreferenceFunc(func(chooser, candidate *Swimbot, pond *Pond, r *rand.Rand) float64 {
+			return -math.Abs(float64(candidate.botGene.numSegments - chooser.botGene.numSegments))
+		}))
+	RegisterMatePreference("similar-length", "choose mate with similar main segment length.",
+		MatePreferenceFunc(func(chooser, candidate *Swimbot, pond *Pond, r *rand.Rand) float64 {
+			return -math.Abs(candidate.segGenes[0][4] - chooser.segGenes[0][4])
+		}))
+}
+
+// AcceptsSuitor is used in the mutual choice mode: the bot scores the suitor with its own preference,
+// compares it to the other bots it could mate with, and accepts the suitor if no more than
+// the fraction acceptFraction of them score higher.
+func (bot *Swimbot) AcceptsSuitor(suitor *Swimbot, pond *Pond, viewRange, acceptFraction float64, preference MatePreference, r *rand.Rand) bool {
+	candidates := bot.SuitableMates(pond, viewRange)
+	suitorScore := preference.Score(bot, suitor, pond, r)
+	better := 0
+	for _, candidate := range candidates {
+		if candidate != suitor && preference.Score(bot, candidate, pond, r) > suitorScore {
+			better++
+		}
+	}
+	// the suitor itself counts as one of the options, even if it is not among the candidates
+	numOptions := len(candidates)
+	if !containsSwimbot(candidates, suitor) {
+		numOptions++
+	}
+	return float64(better) < acceptFraction*float64(numOptions)
+}
+
+// rejectionMemory is the number of generations a bot remembers a rejection and doesn't court the bot that rejected it
+const rejectionMemory = 100.0
+
+// Reject records that the bot was rejected by the bot with the given ID:
+// courting cost the bot some energy, and it gives up its goal and won't court that bot again until it forgets the rejection
+func (bot *Swimbot) Reject(botID int, rejectionCost float64) {
+	bot.rejections++
+	bot.energy -= rejectionCost
+	if bot.rejectedBy == nil {
+		bot.rejectedBy = make(map[int]float64)
+	}
+	bot.rejectedBy[botID] = bot.age
+	bot.goal.id = -1
+}
+
+// ForgetRejections drops the rejections the bot got at least rejectionMemory generations ago,
+// so the bots that rejected it are worth courting again and the record doesn't grow for the whole life of the bot
+func (bot *Swimbot) ForgetRejections() {
+	for botID, age := range bot.rejectedBy {
+		if bot.age-age >= rejectionMemory {
+			delete(bot.rejectedBy, botID)
+		}
+	}
+}
+
+// containsSwimbot returns true if bot is one of the swimbots
+func containsSwimbot(swimbots []*Swimbot, bot *Swimbot) bool {
+	for _, b := range swimbots {
+		if b == bot {
+			return true
+		}
+	}
+	return false
+}
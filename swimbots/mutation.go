@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// The ranges of the traits, RandomGenome draws every trait from these ranges and mutations are clamped to them.
+const (
+	minColor = 0.0
+	maxColor = 255.0
+
+	minAngleToParent = -math.Pi / 2.0
+	maxAngleToParent = math.Pi / 2.0
+
+	minSegmentLength = 5.0
+	maxSegmentLength = 20.0
+
+	minSegmentWidth = 0.3
+	maxSegmentWidth = 4.0
+
+	minAngularMovement = 0.0
+	maxAngularMovement = math.Pi / 4.0
+
+	// RandomGenome never draws exactly 0, and a bot that can't move breaks the angle computation in UpdateVelocity
+	minTranslationalMovement = 0.01
+	maxTranslationalMovement = 10.0
+
+	// RandomGenome draws initialGenomeLength segment genes, duplications let a genome grow up to maxGenomeLength
+	// and a bot expresses at most as many segments as it has genes
+	minNumSegments      = 2
+	maxNumSegments      = maxGenomeLength
+	initialGenomeLength = 8
+	maxGenomeLength     = 32
+
+	minOscillationAmplitude = 0.0
+	maxOscillationAmplitude = math.Pi / 4.0
+
+	// the phase wraps around, but it is clamped like the other traits to keep things simple
+	minOscillationPhase = 0.0
+	maxOscillationPhase = 2 * math.Pi
+
+	minDiet = 0.0
+	maxDiet = 1.0
+
+	// the attachment gene picks the segment a segment is attached to, see AttachmentIndex
+	minAttachment = 0.0
+	maxAttachment = 1.0
+
+	// the behaviour genes, only used with behaviourGenes
+	minViewRange = 10.0
+	maxViewRange = 1000.0
+	// a bot only mates with at least hungerThreshold energy, so the floor is the least energy a child starts with.
+	// Without it the gene evolves toward zero and starving bots breed into an ever growing population.
+	minHungerThreshold = 20.0
+	maxHungerThreshold = 500.0
+)
+
+// TraitMutation describes how a continuous trait mutates:
+// every time a child inherits the trait, it is perturbed with probability rate by a Gaussian with standard deviation sigma.
+type TraitMutation struct {
+	rate  float64
+	sigma float64
+}
+
+// MutationConfig holds the mutation parameters of every trait of the genome
+type MutationConfig struct {
+	color                 TraitMutation // applied to the red, green and blue trait separately
+	angleToParent         TraitMutation
+	length                TraitMutation
+	width                 TraitMutation
+	angularMovement       TraitMutation
+	translationalMovement TraitMutation
+	oscillationAmplitude  TraitMutation
+	oscillationPhase      TraitMutation
+	attachment            TraitMutation
+	diet                  TraitMutation // only used with predation
+	viewRange             TraitMutation // only used with behaviour genes, like hungerThreshold and preference
+	hungerThreshold       TraitMutation
+	brain                 TraitMutation // applied to every weight of the neural network separately, only used with neural steering
+	numSegments           float64       // probability that numSegments moves one step up or down
+	duplication           float64       // probability that a segment gene is copied next to itself
+	deletion              float64       // probability that a segment gene is removed
+	preference            float64       // probability that the mate preference switches to a random registered one
+}
+
+// NewMutationConfig returns the default mutation parameters: no trait mutates, so a child only recombines
+// the genes of its parents like in the baseline simulation. EnableMutations turns mutations on.
+// The standard deviations are set anyway, about 5% of the range of every trait, so turning on a single rate is enough.
+func NewMutationConfig() MutationConfig {
+	var mutation MutationConfig
+	mutation.color = TraitMutation{0, 12}
+	mutation.angleToParent = TraitMutation{0, 0.15}
+	mutation.length = TraitMutation{0, 0.75}
+	mutation.width = TraitMutation{0, 0.2}
+	mutation.angularMovement = TraitMutation{0, 0.04}
+	mutation.translationalMovement = TraitMutation{0, 0.5}
+	mutation.oscillationAmplitude = TraitMutation{0, 0.04}
+	mutation.oscillationPhase = TraitMutation{0, 0.3}
+	mutation.attachment = TraitMutation{0, 0.05}
+	mutation.diet = TraitMutation{0, 0.05}
+	mutation.viewRange = TraitMutation{0, 50}
+	mutation.hungerThreshold = TraitMutation{0, 25}
+	mutation.brain = TraitMutation{0, 0.4}
+	return mutation
+}
+
+// EnableMutations sets the suggested mutation rates: every continuous trait mutates in 5% of the births,
+// the number of expressed segments changes in 5%, a segment gene is duplicated or deleted in 1%
+// and the mate preference switches in 1%
+func (mutation *MutationConfig) EnableMutations() {
+	for _, trait := range []*TraitMutation{
+		&mutation.color, &mutation.angleToParent, &mutation.length, &mutation.width,
+		&mutation.angularMovement, &mutation.translationalMovement, &mutation.oscillationAmplitude, &mutation.oscillationPhase,
+		&mutation.attachment, &mutation.diet, &mutation.viewRange, &mutation.hungerThreshold, &mutation.brain,
+	} {
+		trait.rate = 0.05
+	}
+	mutation.numSegments = 0.05
+	mutation.duplication = 0.01
+	mutation.deletion = 0.01
+	mutation.preference = 0.01
+}
+
+// Validate checks that every rate is a probability and every standard deviation is non-negative
+func (mutation MutationConfig) Validate() error {
+	traits := map[string]TraitMutation{
+		"color":                 mutation.color,
+		"angleToParent":         mutation.angleToParent,
+		"length":                mutation.length,
+		"width":                 mutation.width,
+		"angularMovement":       mutation.angularMovement,
+		"translationalMovement": mutation.translationalMovement,
+		"oscillationAmplitude":  mutation.oscillationAmplitude,
+		"oscillationPhase":      mutation.oscillationPhase,
+		"attachment":            mutation.attachment,
+		"diet":                  mutation.diet,
+		"viewRange":             mutation.viewRange,
+		"hungerThreshold":       mutation.hungerThreshold,
+		"brain":                 mutation.brain,
+	}
+	for name, trait := range traits {
+		if trait.rate < 0 || trait.rate > 1 {
+			return fmt.Errorf("mutation rate of %s must be between 0 and 1, got %v", name, trait.rate)
+		}
+		if trait.sigma < 0 {
+			return fmt.Errorf("mutation sigma of %s must be non-negative, got %v", name, trait.sigma)
+		}
+	}
+	if mutation.numSegments < 0 || mutation.numSegments > 1 {
+		return fmt.Errorf("mutation rate of numSegments must be between 0 and 1, got %v", mutation.numSegments)
+	}
+	if mutation.duplication < 0 || mutation.duplication > 1 || mutation.deletion < 0 || mutation.deletion > 1 {
+		return fmt.Errorf("duplication and deletion rates must be between 0 and 1, got %v and %v", mutation.duplication, mutation.deletion)
+	}
+	if mutation.preference < 0 || mutation.preference > 1 {
+		return fmt.Errorf("mutation rate of preference must be between 0 and 1, got %v", mutation.preference)
+	}
+	return nil
+}
+
+// MutateGenome mutates a freshly recombined genome, drawing every mutation from r.
+// The traits change in place, but a duplication or a deletion changes the length of the genome,
+// so the caller has to use the returned segment genes.
+func MutateGenome(common *CommonGene, segGenes []SegmentGene, mutation MutationConfig, r *rand.Rand) []SegmentGene {
+	common.angularMovement = mutation.angularMovement.Apply(common.angularMovement, minAngularMovement, maxAngularMovement, r)
+	common.translationalMovement = mutation.translationalMovement.Apply(common.translationalMovement, minTranslationalMovement, maxTranslationalMovement, r)
+	common.diet = mutation.diet.Apply(common.diet, minDiet, maxDiet, r)
+	common.viewRange = mutation.viewRange.Apply(common.viewRange, minViewRange, maxViewRange, r)
+	common.hungerThreshold = mutation.hungerThreshold.Apply(common.hungerThreshold, minHungerThreshold, maxHungerThreshold, r)
+	// a preference has no order, so it jumps to any of the registered preferences
+	if mutation.preference > 0 && r.Float64() < mutation.preference {
+		common.preference = r.Intn(len(matePreferences))
+	}
+
+	// the number of segments moves by one step at a time
+	if mutation.numSegments > 0 && r.Float64() < mutation.numSegments {
+		if r.Intn(2) == 0 {
+			common.numSegments--
+		} else {
+			common.numSegments++
+		}
+		if common.numSegments < minNumSegments {
+			common.numSegments = minNumSegments
+		}
+		// a bot can't express more segments than it has genes
+		if common.numSegments > len(segGenes) {
+			common.numSegments = len(segGenes)
+		}
+	}
+
+	for i := range segGenes {
+		for c := 0; c < 3; c++ {
+			segGenes[i][c] = mutation.color.Apply(segGenes[i][c], minColor, maxColor, r)
+		}
+		segGenes[i][3] = mutation.angleToParent.Apply(segGenes[i][3], minAngleToParent, maxAngleToParent, r)
+		segGenes[i][4] = mutation.length.Apply(segGenes[i][4], minSegmentLength, maxSegmentLength, r)
+		segGenes[i][5] = mutation.width.Apply(segGenes[i][5], minSegmentWidth, maxSegmentWidth, r)
+		segGenes[i][6] = mutation.oscillationAmplitude.Apply(segGenes[i][6], minOscillationAmplitude, maxOscillationAmplitude, r)
+		segGenes[i][7] = mutation.oscillationPhase.Apply(segGenes[i][7], minOscillationPhase, maxOscillationPhase, r)
+		segGenes[i][8] = mutation.attachment.Apply(segGenes[i][8], minAttachment, maxAttachment, r)
+	}
+
+	// a duplicate starts out identical to its original and drifts apart in later generations
+	if mutation.duplication > 0 && r.Float64() < mutation.duplication && len(segGenes) < maxGenomeLength {
+		segGenes = DuplicateSegmentGene(segGenes, r.Intn(len(segGenes)))
+	}
+	if mutation.deletion > 0 && r.Float64() < mutation.deletion && len(segGenes) > minNumSegments {
+		segGenes = DeleteSegmentGene(segGenes, r.Intn(len(segGenes)))
+		if common.numSegments > len(segGenes) {
+			common.numSegments = len(segGenes)
+		}
+	}
+	return segGenes
+}
+
+// DuplicateSegmentGene returns the genes with a copy of gene k inserted right after it.
+// The genes behind it move back by one, so the last expressed segment may fall silent.
+func DuplicateSegmentGene(segGenes []SegmentGene, k int) []SegmentGene {
+	duplicated := make([]SegmentGene, 0, len(segGenes)+1)
+	duplicated = append(duplicated, segGenes[:k+1]...)
+	duplicated = append(duplicated, append(SegmentGene{}, segGenes[k]...))
+	return append(duplicated, segGenes[k+1:]...)
+}
+
+// DeleteSegmentGene returns the genes without gene k, the genes behind it move forward by one
+func DeleteSegmentGene(segGenes []SegmentGene, k int) []SegmentGene {
+	deleted := make([]SegmentGene, 0, len(segGenes)-1)
+	deleted = append(deleted, segGenes[:k]...)
+	return append(deleted, segGenes[k+1:]...)
+}
+
+// Apply returns the value after a possible mutation, clamped to [min, max].
+// A trait with a zero rate doesn't draw from r, so turning mutations off gives back the exact same run.
+func (trait TraitMutation) Apply(value, min, max float64, r *rand.Rand) float64 {
+	if trait.rate == 0 || r.Float64() >= trait.rate {
+		return value
+	}
+	return Clamp(value+r.NormFloat64()*trait.sigma, min, max)
+}
+
+// Clamp returns the value closest to x within [min, max]
+func Clamp(x, min, max float64) float64 {
+	return math.Max(min, math.Min(max, x))
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bufio"
+	"canvas"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Obstacle is a static object inside the pond. Bots can't swim through it and can't see through it.
+type Obstacle interface {
+	// Blocks returns true if the straight line from a to b runs into the obstacle
+	Blocks(a, b OrderedPair) bool
+	// Normal returns the unit vector pointing from the obstacle towards p, used to bounce a bot off the obstacle
+	Normal(p OrderedPair) OrderedPair
+	// Draw draws the obstacle on the canvas, scale converts pond coordinates into pixels
+	Draw(c *canvas.Canvas, scale float64)
+}
+
+// CircleObstacle is a solid disk
+type CircleObstacle struct {
+	center OrderedPair
+	radius float64
+}
+
+// RectObstacle is a solid rectangle with sides parallel to the edges of the pond
+type RectObstacle struct {
+	min, max OrderedPair // lower left and upper right corner
+}
+
+// PolylineObstacle is a wall made of straight pieces between consecutive points
+type PolylineObstacle struct {
+	points    []OrderedPair
+	thickness float64
+}
+
+// Blocks returns true if the line from a to b comes closer to the center than the radius
+func (o *CircleObstacle) Blocks(a, b OrderedPair) bool {
+	return pointSegmentDistance(o.center, a, b) <= o.radius
+}
+
+// Normal points from the center to p
+func (o *CircleObstacle) Normal(p OrderedPair) OrderedPair {
+	return unitVector(OrderedPair{p.x - o.center.x, p.y - o.center.y}, OrderedPair{1, 0})
+}
+
+// Draw draws the disk
+func (o *CircleObstacle) Draw(c *canvas.Canvas, scale float64) {
+	c.Circle(o.center.x*scale, o.center.y*scale, o.radius*scale)
+	c.Fill()
+}
+
+// Blocks returns true if a or b is inside the rectangle or the line from a to b crosses one of its sides
+func (o *RectObstacle) Blocks(a, b OrderedPair) bool {
+	if o.contains(a) || o.contains(b) {
+		return true
+	}
+	corners := o.corners()
+	for i := range corners {
+		if segmentsIntersect(a, b, corners[i], corners[(i+1)%4]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Normal points from the closest point of the rectangle to p, or out of the closest side if p is inside
+func (o *RectObstacle) Normal(p OrderedPair) OrderedPair {
+	closest := OrderedPair{Clamp(p.x, o.min.x, o.max.x), Clamp(p.y, o.min.y, o.max.y)}
+	if closest != p {
+		return unitVector(OrderedPair{p.x - closest.x, p.y - closest.y}, OrderedPair{1, 0})
+	}
+	// p is inside, leave through the closest side
+	sides := []struct {
+		distance float64
+		normal   OrderedPair
+	}{
+		{p.x - o.min.x, OrderedPair{-1, 0}},
+		{o.max.x - p.x, OrderedPair{1, 0}},
+		{p.y - o.min.y, OrderedPair{0, -1}},
+		{o.max.y - p.y, OrderedPair{0, 1}},
+	}
+	best := 0
+	for i := range sides {
+		if sides[i].distance < sides[best].distance {
+			best = i
+		}
+	}
+	return sides[best].normal
+}
+
+// Draw draws the rectangle
+func (o *RectObstacle) Draw(c *canvas.Canvas, scale float64) {
+	corners := o.corners()
+	c.MoveTo(corners[0].x*scale, corners[0].y*scale)
+	for _, corner := range corners[1:] {
+		c.LineTo(corner.x*scale, corner.y*scale)
+	}
+	c.LineTo(corners[0].x*scale, corners[0].y*scale)
+	c.Fill()
+}
+
+// contains returns true if p is inside the rectangle or on its sides
+func (o *RectObstacle) contains(p OrderedPair) bool {
+	return p.x >= o.min.x && p.x <= o.max.x && p.y >= o.min.y && p.y <= o.max.y
+}
+
+// corners returns the corners of the rectangle in order around it
+func (o *RectObstacle) corners() []OrderedPair {
+	return []OrderedPair{o.min, {o.max.x, o.min.y}, o.max, {o.min.x, o.max.y}}
+}
+
+// Blocks returns true if the line from a to b comes closer than half the thickness to one of the pieces of the wall
+func (o *PolylineObstacle) Blocks(a, b OrderedPair) bool {
+	for i := 1; i < len(o.points); i++ {
+		if segmentDistance(a, b, o.points[i-1], o.points[i]) <= o.thickness/2 {
+			return true
+		}
+	}
+	return false
+}
+
+// Normal points from the closest piece of the wall to p
+func (o *PolylineObstacle) Normal(p OrderedPair) OrderedPair {
+	closestIndex := 1
+	closestDistance := math.Inf(1)
+	for i := 1; i < len(o.points); i++ {
+		if d := pointSegmentDistance(p, o.points[i-1], o.points[i]); d < closestDistance {
+			closestIndex, closestDistance = i, d
+		}
+	}
+	start, end := o.points[closestIndex-1], o.points[closestIndex]
+	closest := closestPointOnSegment(p, start, end)
+	// a point right on the wall leaves it sideways
+	sideways := OrderedPair{start.y - end.y, end.x - start.x}
+	return unitVector(OrderedPair{p.x - closest.x, p.y - closest.y}, unitVector(sideways, OrderedPair{1, 0}))
+}
+
+// Draw draws the wall as a thick line
+func (o *PolylineObstacle) Draw(c *canvas.Canvas, scale float64) {
+	c.SetLineWidth(o.thickness * scale)
+	c.MoveTo(o.points[0].x*scale, o.points[0].y*scale)
+	for _, p := range o.points[1:] {
+		c.LineTo(p.x*scale, p.y*scale)
+	}
+	c.Stroke()
+}
+
+// ObstacleBetween returns the first obstacle of the pond that blocks the line from a to b, or nil if there is none.
+// In a wrapping pond the line takes the shortest way from a to b, the way Displacement measures.
+// That line may leave the pond and come back in on the other side, so it is also tested shifted by
+// the width and the height of the pond, where the part beyond the edge meets the obstacles it really passes.
+func (pond *Pond) ObstacleBetween(a, b OrderedPair) Obstacle {
+	if len(pond.obstacles) == 0 {
+		return nil
+	}
+	delta := pond.Displacement(a, b)
+	shifts := []OrderedPair{{0, 0}}
+	if pond.boundary == wrapBoundary {
+		shifts = shifts[:0]
+		for _, dx := range []float64{0, -pond.width, pond.width} {
+			for _, dy := range []float64{0, -pond.height, pond.height} {
+				shifts = append(shifts, OrderedPair{dx, dy})
+			}
+		}
+	}
+	for _, o := range pond.obstacles {
+		for _, shift := range shifts {
+			from := OrderedPair{a.x + shift.x, a.y + shift.y}
+			to := OrderedPair{from.x + delta.x, from.y + delta.y}
+			if o.Blocks(from, to) {
+				return o
+			}
+		}
+	}
+	return nil
+}
+
+// Contact returns the obstacle a bot swimming from the free position a to b runs into, and the point where it touches it.
+// The point is found by bisection, so Normal there belongs to the part of the obstacle that is actually in the way,
+// which in the inner corner of a wall isn't always the part closest to a. In a wrapping pond the point is wrapped into the pond.
+func (pond *Pond) Contact(a, b OrderedPair) (Obstacle, OrderedPair) {
+	obstacle := pond.ObstacleBetween(a, b)
+	if obstacle == nil {
+		return nil, b
+	}
+	delta := pond.Displacement(a, b)
+	free, blocked := 0.0, 1.0
+	for n := 0; n < 30; n++ {
+		t := (free + blocked) / 2
+		if o := pond.ObstacleBetween(a, OrderedPair{a.x + t*delta.x, a.y + t*delta.y}); o != nil {
+			blocked, obstacle = t, o
+		} else {
+			free = t
+		}
+	}
+	contact := OrderedPair{a.x + blocked*delta.x, a.y + blocked*delta.y}
+	if pond.boundary == wrapBoundary {
+		contact = OrderedPair{wrapCoordinate(contact.x, pond.width), wrapCoordinate(contact.y, pond.height)}
+	}
+	return obstacle, contact
+}
+
+// LineOfSight returns true if no obstacle is in the way between a and b
+func (pond *Pond) LineOfSight(a, b OrderedPair) bool {
+	return pond.ObstacleBetween(a, b) == nil
+}
+
+// Blocked returns true if the position p is inside an obstacle
+func (pond *Pond) Blocked(p OrderedPair) bool {
+	return pond.ObstacleBetween(p, p) != nil
+}
+
+// ReadObstacles reads the obstacles of a pond from a file with one obstacle per line:
+//
+//	circle x y radius
+//	rect x1 y1 x2 y2
+//	polyline thickness x1 y1 x2 y2 ...
+//
+// Empty lines and lines starting with # are skipped.
+func ReadObstacles(filename string) ([]Obstacle, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	obstacles := make([]Obstacle, 0)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		values := make([]float64, len(fields)-1)
+		for i := range values {
+			values[i], err = strconv.ParseFloat(fields[i+1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", filename, lineNumber, err)
+			}
+		}
+		obstacle, err := NewObstacle(fields[0], values)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filename, lineNumber, err)
+		}
+		obstacles = append(obstacles, obstacle)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return obstacles, nil
+}
+
+// NewObstacle builds an obstacle of the given kind ("circle", "rect" or "polyline") from the values of its line, see ReadObstacles
+func NewObstacle(kind string, values []float64) (Obstacle, error) {
+	switch kind {
+	case "circle":
+		if len(values) != 3 || values[2] <= 0 {
+			return nil, fmt.Errorf("a circle needs a center and a positive radius")
+		}
+		return &CircleObstacle{OrderedPair{values[0], values[1]}, values[2]}, nil
+	case "rect":
+		if len(values) != 4 {
+			return nil, fmt.Errorf("a rect needs two corners")
+		}
+		min := OrderedPair{math.Min(values[0], values[2]), math.Min(values[1], values[3])}
+		max := OrderedPair{math.Max(values[0], values[2]), math.Max(values[1], values[3])}
+		return &RectObstacle{min, max}, nil
+	case "polyline":
+		if len(values) < 5 || len(values)%2 != 1 || values[0] <= 0 {
+			return nil, fmt.Errorf("a polyline needs a positive thickness and at least two points")
+		}
+		points := make([]OrderedPair, 0, len(values)/2)
+		for i := 1; i < len(values); i += 2 {
+			points = append(points, OrderedPair{values[i], values[i+1]})
+		}
+		return &PolylineObstacle{points, values[0]}, nil
+	}
+	return nil, fmt.Errorf("unknown obstacle %q", kind)
+}
+
+// closestPointOnSegment returns the point of the segment from a to b closest to p
+func closestPointOnSegment(p, a, b OrderedPair) OrderedPair {
+	ab := OrderedPair{b.x - a.x, b.y - a.y}
+	lengthSquared := ab.x*ab.x + ab.y*ab.y
+	if lengthSquared == 0 {
+		return a
+	}
+	t := Clamp(((p.x-a.x)*ab.x+(p.y-a.y)*ab.y)/lengthSquared, 0, 1)
+	return OrderedPair{a.x + t*ab.x, a.y + t*ab.y}
+}
+
+// pointSegmentDistance returns the distance from p to the segment from a to b
+func pointSegmentDistance(p, a, b OrderedPair) float64 {
+	closest := closestPointOnSegment(p, a, b)
+	return math.Hypot(p.x-closest.x, p.y-closest.y)
+}
+
+// segmentDistance returns the distance between the segment from a to b and the segment from c to d
+func segmentDistance(a, b, c, d OrderedPair) float64 {
+	if segmentsIntersect(a, b, c, d) {
+		return 0
+	}
+	return math.Min(math.Min(pointSegmentDistance(a, c, d), pointSegmentDistance(b, c, d)),
+		math.Min(pointSegmentDistance(c, a, b), pointSegmentDistance(d, a, b)))
+}
+
+// segmentsIntersect returns true if the segment from a to b crosses or touches the segment from c to d
+func segmentsIntersect(a, b, c, d OrderedPair) bool {
+	d1 := cross(c, d, a)
+	d2 := cross(c, d, b)
+	d3 := cross(a, b, c)
+	d4 := cross(a, b, d)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	// the segments touch if an end point lies on the other segment
+	return (d1 == 0 && pointSegmentDistance(a, c, d) == 0) || (d2 == 0 && pointSegmentDistance(b, c, d) == 0) ||
+		(d3 == 0 && pointSegmentDistance(c, a, b) == 0) || (d4 == 0 && pointSegmentDistance(d, a, b) == 0)
+}
+
+// cross returns the cross product of b-a and p-a, its sign tells on which side of the line through a and b p lies
+func cross(a, b, p OrderedPair) float64 {
+	return (b.x-a.x)*(p.y-a.y) - (b.y-a.y)*(p.x-a.x)
+}
+
+// unitVector returns v scaled to length 1, or fallback if v has no length
+func unitVector(v, fallback OrderedPair) OrderedPair {
+	length := math.Hypot(v.x, v.y)
+	if length == 0 {
+		return fallback
+	}
+	return OrderedPair{v.x / length, v.y / length}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// PedigreeRecord is the entry of a single bot in the pedigree.
+// The mother is the bot that chose its mate, the father is the bot it chose.
+type PedigreeRecord struct {
+	id        int
+	mother    int // -1 for the bots of the initial pond
+	father    int // -1 for the bots of the initial pond
+	birthStep int
+	deathStep int // -1 while the bot is alive
+}
+
+// Pedigree records the parents, birth and death of every bot ever born in a run.
+// It belongs to the whole run, so every pond of the run points to the same pedigree.
+type Pedigree struct {
+	records  map[int]*PedigreeRecord
+	children map[int][]int
+	order    []int // IDs in the order the bots were born, used to export the pedigree
+}
+
+// NewPedigree returns an empty pedigree
+func NewPedigree() *Pedigree {
+	var p Pedigree
+	p.records = make(map[int]*PedigreeRecord)
+	p.children = make(map[int][]int)
+	return &p
+}
+
+// RecordBirth adds a bot to the pedigree. Use -1 as mother and father for a bot without parents.
+func (p *Pedigree) RecordBirth(id, mother, father, step int) {
+	var record PedigreeRecord
+	record.id = id
+	record.mother = mother
+	record.father = father
+	record.birthStep = step
+	record.deathStep = -1
+
+	p.records[id] = &record
+	p.order = append(p.order, id)
+	if mother != -1 {
+		p.children[mother] = append(p.children[mother], id)
+	}
+	if father != -1 && father != mother {
+		p.children[father] = append(p.children[father], id)
+	}
+}
+
+// RecordDeath sets the step at which a bot of the pedigree died
+func (p *Pedigree) RecordDeath(id, step int) {
+	if record, exists := p.records[id]; exists {
+		record.deathStep = step
+	}
+}
+
+// Record returns the entry of a bot, or nil if the bot is not in the pedigree
+func (p *Pedigree) Record(id int) *PedigreeRecord {
+	return p.records[id]
+}
+
+// Parents returns the IDs of the known parents of a bot
+func (p *Pedigree) Parents(id int) []int {
+	parents := make([]int, 0, 2)
+	record, exists := p.records[id]
+	if !exists {
+		return parents
+	}
+	if record.mother != -1 {
+		parents = append(parents, record.mother)
+	}
+	if record.father != -1 && record.father != record.mother {
+		parents = append(parents, record.father)
+	}
+	return parents
+}
+
+// Children returns the IDs of the children of a bot, in the order they were born
+func (p *Pedigree) Children(id int) []int {
+	return p.children[id]
+}
+
+// Ancestors returns the IDs of every ancestor of a bot (parents, grandparents, ...) in increasing order
+func (p *Pedigree) Ancestors(id int) []int {
+	return p.walk(id, p.Parents)
+}
+
+// Descendants returns the IDs of every descendant of a bot (children, grandchildren, ...) in increasing order
+func (p *Pedigree) Descendants(id int) []int {
+	return p.walk(id, p.Children)
+}
+
+// CommonAncestors returns the IDs of the ancestors shared by two bots in increasing order
+func (p *Pedigree) CommonAncestors(id1, id2 int) []int {
+	ancestors1 := make(map[int]bool)
+	for _, a := range p.Ancestors(id1) {
+		ancestors1[a] = true
+	}
+	common := make([]int, 0)
+	for _, a := range p.Ancestors(id2) {
+		if ancestors1[a] {
+			common = append(common, a)
+		}
+	}
+	return common
+}
+
+// walk collects every bot reachable from id by repeatedly following next, not including id itself
+func (p *Pedigree) walk(id int, next func(int) []int) []int {
+	visited := make(map[int]bool)
+	queue := next(id)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		queue = append(queue, next(current)...)
+	}
+
+	ids := make([]int, 0, len(visited))
+	for v := range visited {
+		ids = append(ids, v)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// WritePedigreeCSV writes the pedigree into two csv files:
+// filename+"Nodes.csv" with the parents, birth and death step of every bot
+// and filename+"Edges.csv" with one parent to child edge per line.
+func (p *Pedigree) WritePedigreeCSV(filename string) {
+	nodes := make([][]string, 0, len(p.order)+1)
+	nodes = append(nodes, []string{"id", "mother", "father", "birthStep", "deathStep"})
+	edges := make([][]string, 0, 2*len(p.order)+1)
+	edges = append(edges, []string{"parent", "child", "role"})
+
+	for _, id := range p.order {
+		record := p.records[id]
+		nodes = append(nodes, []string{strconv.Itoa(id), strconv.Itoa(record.mother), strconv.Itoa(record.father), strconv.Itoa(record.birthStep), strconv.Itoa(record.deathStep)})
+		if record.mother != -1 {
+			edges = append(edges, []string{strconv.Itoa(record.mother), strconv.Itoa(id), "mother"})
+		}
+		if record.father != -1 {
+			edges = append(edges, []string{strconv.Itoa(record.father), strconv.Itoa(id), "father"})
+		}
+	}
+
+	WriteRowsToCSV(nodes, filename+"Nodes")
+	WriteRowsToCSV(edges, filename+"Edges")
+}
+
+// WriteRowsToCSV writes every row into the csv file filename+".csv"
+func WriteRowsToCSV(rows [][]string, filename string) {
+	csvFile, err := os.Create(filename + ".csv")
+	if err != nil {
+		panic(err)
+	}
+	defer csvFile.Close()
+
+	csvwriter := csv.NewWriter(csvFile)
+	if err := csvwriter.WriteAll(rows); err != nil {
+		panic(err)
+	}
+}
+
+// WritePedigreeDOT writes the pedigree as a Graphviz graph into filename+".dot".
+// Every bot is a node labelled with its birth and death step, every edge goes from a parent to a child.
+func (p *Pedigree) WritePedigreeDOT(filename string) {
+	dotFile, err := os.Create(filename + ".dot")
+	if err != nil {
+		panic(err)
+	}
+	defer dotFile.Close()
+
+	fmt.Fprintln(dotFile, "digraph pedigree {")
+	fmt.Fprintln(dotFile, "\tnode [shape=box];")
+	for _, id := range p.order {
+		record := p.records[id]
+		if record.deathStep == -1 {
+			// the bots still alive at the end are drawn in bold
+			fmt.Fprintf(dotFile, "\t%d [label=\"%d\\nborn %d\", style=bold];\n", id, id, record.birthStep)
+		} else {
+			fmt.Fprintf(dotFile, "\t%d [label=\"%d\\nborn %d\\ndied %d\"];\n", id, id, record.birthStep, record.deathStep)
+		}
+	}
+	for _, id := range p.order {
+		for _, parent := range p.Parents(id) {
+			fmt.Fprintf(dotFile, "\t%d -> %d;\n", parent, id)
+		}
+	}
+	_, err = fmt.Fprintln(dotFile, "}")
+	if err != nil {
+		panic(err)
+	}
+}
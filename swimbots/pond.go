@@ -0,0 +1,135 @@
+package main
+
+import (
+	"math/rand"
+)
+
+// Every swimbot and every food bit gets a permanent ID when it is added to the pond.
+// Goals and families refer to these IDs instead of positions in the slices,
+// and the pond keeps a map from every ID to the position of the object in its slice.
+
+// AddSwimbot gives the bot the next free ID and appends it to the pond
+func (pond *Pond) AddSwimbot(bot *Swimbot) {
+	if pond.botSlots == nil {
+		pond.botSlots = make(map[int]int)
+	}
+	bot.id = pond.nextBotID
+	pond.nextBotID++
+	pond.botSlots[bot.id] = len(pond.swimbots)
+	pond.swimbots = append(pond.swimbots, bot)
+}
+
+// AddFoodBit gives the food bit the next free ID and appends it to the pond
+func (pond *Pond) AddFoodBit(f *Food) {
+	if pond.foodSlots == nil {
+		pond.foodSlots = make(map[int]int)
+	}
+	f.id = pond.nextFoodID
+	pond.nextFoodID++
+	pond.foodSlots[f.id] = len(pond.foodBits)
+	pond.foodBits = append(pond.foodBits, f)
+}
+
+// DropFood adds a new food bit at the given position, its type is drawn from the food types of the pond
+func (pond *Pond) DropFood(position OrderedPair, r *rand.Rand) {
+	var f Food
+	f.position = position
+	f.foodType = RandomFoodType(pond.foodTypes, r)
+	pond.AddFoodBit(&f)
+}
+
+// FreePosition returns true if p lies inside the pond and not inside an obstacle
+func (pond *Pond) FreePosition(p OrderedPair) bool {
+	return p.x >= 0 && p.x <= pond.width && p.y >= 0 && p.y <= pond.height && !pond.Blocked(p)
+}
+
+// SwimbotByID returns the living bot with the given ID, or nil if it died or never existed
+func (pond *Pond) SwimbotByID(id int) *Swimbot {
+	slot, exists := pond.botSlots[id]
+	if !exists {
+		return nil
+	}
+	return pond.swimbots[slot]
+}
+
+// FoodByID returns the food bit with the given ID, or nil if it was eaten or never existed
+func (pond *Pond) FoodByID(id int) *Food {
+	slot, exists := pond.foodSlots[id]
+	if !exists {
+		return nil
+	}
+	return pond.foodBits[slot]
+}
+
+// RemoveFoodByID removes the food bit with the given ID from the pond
+func (pond *Pond) RemoveFoodByID(id int) {
+	if slot, exists := pond.foodSlots[id]; exists {
+		pond.foodBits[slot] = nil
+	}
+}
+
+// CopySlots returns a copy of a map from IDs to slots
+func CopySlots(slots map[int]int) map[int]int {
+	newSlots := make(map[int]int, len(slots))
+	for id, slot := range slots {
+		newSlots[id] = slot
+	}
+	return newSlots
+}
+
+// Compact removes the dead bots and the eaten food bits from the slices of the pond
+// and moves every remaining object to its new slot. Goals and families refer to IDs,
+// so they stay valid; the IDs of the removed objects simply can't be found anymore.
+func (pond *Pond) Compact() {
+	swimbots := make([]*Swimbot, 0, len(pond.botSlots))
+	botSlots := make(map[int]int, len(pond.botSlots))
+	for _, b := range pond.swimbots {
+		if b != nil {
+			botSlots[b.id] = len(swimbots)
+			swimbots = append(swimbots, b)
+		}
+	}
+
+	foodBits := make([]*Food, 0, len(pond.foodSlots))
+	foodSlots := make(map[int]int, len(pond.foodSlots))
+	for _, f := range pond.foodBits {
+		if f != nil {
+			foodSlots[f.id] = len(foodBits)
+			foodBits = append(foodBits, f)
+		}
+	}
+
+	pond.swimbots, pond.botSlots = swimbots, botSlots
+	pond.foodBits, pond.foodSlots = foodBits, foodSlots
+}
+
+// The bots of a new pond are put into its middle, the food is spread a bit wider.
+// The margins are fractions of the width and the height of the pond kept free on every side.
+const (
+	spawnMargin = 1.0 / 6.0
+	foodMargin  = 1.0 / 12.0
+
+	// a pond that is almost filled with obstacles gets objects inside them instead of never finishing
+	maxPositionAttempts = 100
+)
+
+// RandomPosition returns a random position in the pond that keeps the margin, a fraction of the width
+// and the height, from every edge. Like the rest of the pond it only uses whole coordinates.
+// Positions inside an obstacle are drawn again, as long as there are attempts left.
+func (pond *Pond) RandomPosition(r *rand.Rand, margin float64) OrderedPair {
+	position := OrderedPair{randomCoordinate(r, pond.width, margin), randomCoordinate(r, pond.height, margin)}
+	for attempt := 1; attempt < maxPositionAttempts && pond.Blocked(position); attempt++ {
+		position = OrderedPair{randomCoordinate(r, pond.width, margin), randomCoordinate(r, pond.height, margin)}
+	}
+	return position
+}
+
+// randomCoordinate returns a random whole number in [margin*size, (1-margin)*size)
+func randomCoordinate(r *rand.Rand, size, margin float64) float64 {
+	low := int(size * margin)
+	span := int(size - 2*size*margin)
+	if span < 1 {
+		span = 1
+	}
+	return float64(r.Intn(span) + low)
+}
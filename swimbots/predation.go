@@ -0,0 +1,65 @@
+package main
+
+// predatorDiet is the diet from which on a bot hunts other bots instead of eating food bits
+const predatorDiet = 0.5
+
+// The traits that decide an attack, see AttackStrength
+const (
+	sizeAttack     = "size"
+	speedAttack    = "speed"
+	segmentsAttack = "segments"
+)
+
+// IsPredator returns true if the bot hunts other bots when it is hungry.
+// Without predation every diet stays 0, so no bot is a predator.
+func (bot *Swimbot) IsPredator() bool {
+	return bot.botGene.diet >= predatorDiet
+}
+
+// AttackStrength returns how strong the bot is in a fight decided by the given trait:
+// "size" is the area of its body, "speed" the speed it swims at and "segments" its number of segments
+func (bot *Swimbot) AttackStrength(config *SimulationConfig) float64 {
+	switch config.attackTrait {
+	case speedAttack:
+		speed, _ := bot.Locomotion(config)
+		return speed
+	case segmentsAttack:
+		return float64(bot.botGene.numSegments)
+	}
+	return bot.BodyArea()
+}
+
+// FindPrey returns the ID of the closest bot the predator can see and is not related to, or -1 if there is none
+func (bot *Swimbot) FindPrey(pond *Pond, viewRange float64) int {
+	preyID := -1
+	var shortestDist float64
+	for _, i := range pond.SwimbotsNear(bot.position, viewRange) {
+		prey := pond.swimbots[i]
+		// predators don't eat their own family
+		if prey == nil || prey == bot || bot.RelatedTo(prey.id) {
+			continue
+		}
+		dist := bot.DistanceToSwimbot(prey, pond)
+		if dist <= viewRange && (preyID == -1 || dist < shortestDist) && pond.LineOfSight(bot.position, prey.position) {
+			preyID = prey.id
+			shortestDist = dist
+		}
+	}
+	return preyID
+}
+
+// Attack lets the predator at index i attack its prey. The predator wins if it is stronger than the prey:
+// the prey dies and the predator gains the fraction predationEfficiency of the prey's energy.
+// Otherwise the prey escapes, the attack costs the predator attackCost and it gives up the prey.
+func (pond *Pond) Attack(i int, prey *Swimbot, numGen int, config *SimulationConfig) {
+	predator := pond.swimbots[i]
+	if predator.AttackStrength(config) <= prey.AttackStrength(config) {
+		predator.energy -= config.attackCost
+		predator.goal.id = -1
+		return
+	}
+	predator.energy += config.predationEfficiency * prey.energy
+	predator.goal.id = -1
+	pond.swimbots[pond.botSlots[prey.id]] = nil
+	pond.pedigree.RecordDeath(prey.id, numGen)
+}
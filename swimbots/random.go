@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math/rand"
+)
+
+// RandomStreams holds the random number generators of one simulation run.
+// Each subsystem draws from its own stream, so a change in how many numbers one subsystem draws
+// doesn't shift the numbers drawn by the others, and two runs with the same seed give the same pond.
+type RandomStreams struct {
+	seed   int64
+	food   *rand.Rand // placement of the food bits
+	genome *rand.Rand // random genomes and recombination of the parents' genomes
+	spawn  *rand.Rand // initial positions and velocities of the bots
+	choice *rand.Rand // decisions of the bots that are courted in the mutual choice mode
+
+	// mate choice happens while the bots are updated in parallel, so instead of a shared stream
+	// every bot gets its own stream per generation, derived from this seed (see BotStream)
+	mateSeed int64
+}
+
+// NewRandomStreams takes in a seed and derives one independent stream for every subsystem from it
+func NewRandomStreams(seed int64) *RandomStreams {
+	var streams RandomStreams
+	streams.seed = seed
+
+	// the master generator is only used to seed the streams, always in the same order
+	master := rand.New(rand.NewSource(seed))
+	streams.food = rand.New(rand.NewSource(master.Int63()))
+	streams.genome = rand.New(rand.NewSource(master.Int63()))
+	streams.mateSeed = master.Int63()
+	streams.spawn = rand.New(rand.NewSource(master.Int63()))
+	streams.choice = rand.New(rand.NewSource(master.Int63()))
+
+	return &streams
+}
+
+// BotStream returns the random stream of one bot for one generation.
+// It only depends on the seed of the run, the generation and the permanent ID of the bot, so the numbers a bot draws
+// don't depend on which goroutine updates it, on the order the bots are updated in or on the slot the bot sits in.
+func (streams *RandomStreams) BotStream(numGen, botID int) *rand.Rand {
+	var source splitMix64
+	source.state = mixSeed(mixSeed(uint64(streams.mateSeed)+uint64(numGen)) + uint64(botID))
+	return rand.New(&source)
+}
+
+// splitMix64 is a tiny rand.Source64. Seeding a math/rand source is expensive,
+// and BotStream creates one source for every bot in every generation.
+type splitMix64 struct {
+	state uint64
+}
+
+// Seed resets the state of the source
+func (s *splitMix64) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// Uint64 advances the state and returns the next number of the sequence
+func (s *splitMix64) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	return mixSeed(s.state)
+}
+
+// Int63 returns the next number of the sequence without its sign bit
+func (s *splitMix64) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+// mixSeed scrambles the bits of x, so that close inputs give unrelated outputs
+func mixSeed(x uint64) uint64 {
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
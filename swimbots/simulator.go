@@ -0,0 +1,114 @@
+package main
+
+// PondObserver is notified of every pond a Simulator produces.
+// Observers must treat the pond as read-only, since it is the input of the next generation.
+type PondObserver interface {
+	OnStep(gen int, pond *Pond)
+}
+
+// ObserverFunc lets an ordinary function be registered as a PondObserver
+type ObserverFunc func(gen int, pond *Pond)
+
+// OnStep calls the function itself
+func (f ObserverFunc) OnStep(gen int, pond *Pond) {
+	f(gen, pond)
+}
+
+// Simulator runs a simulation one generation at a time.
+// Only the current pond is kept in memory; every pond is handed to the observers as soon as it is produced.
+type Simulator struct {
+	config     *SimulationConfig
+	pond       *Pond
+	generation int
+	started    bool // whether the observers have seen the initial pond
+	observers  []PondObserver
+}
+
+// NewSimulator takes in a config and returns a simulator holding the initial pond of the run
+func NewSimulator(config *SimulationConfig) *Simulator {
+	var sim Simulator
+	sim.config = config
+	sim.pond = InitializePond(config)
+	sim.generation = 0
+	return &sim
+}
+
+// AddObserver registers an observer that will be notified of every pond from now on
+func (sim *Simulator) AddObserver(observer PondObserver) {
+	sim.observers = append(sim.observers, observer)
+}
+
+// Pond returns the current pond of the simulation
+func (sim *Simulator) Pond() *Pond {
+	return sim.pond
+}
+
+// Generation returns the generation of the current pond
+func (sim *Simulator) Generation() int {
+	return sim.generation
+}
+
+// Step updates the pond to the next generation, notifies the observers and returns the new pond.
+// The first call also notifies the observers of the initial pond.
+func (sim *Simulator) Step() *Pond {
+	sim.start()
+	sim.generation++
+	sim.pond = UpdatePond(sim.pond, sim.generation, sim.config)
+	sim.notify()
+	return sim.pond
+}
+
+// Run steps the simulation until it reaches config.numGens generations
+func (sim *Simulator) Run() {
+	sim.start()
+	for sim.generation < sim.config.numGens {
+		sim.Step()
+	}
+}
+
+// start notifies the observers of the initial pond, only once
+func (sim *Simulator) start() {
+	if !sim.started {
+		sim.started = true
+		sim.notify()
+	}
+}
+
+// notify hands the current pond to every observer in the order they were added
+func (sim *Simulator) notify() {
+	for _, observer := range sim.observers {
+		observer.OnStep(sim.generation, sim.pond)
+	}
+}
+
+// HistoryRecorder is an observer that keeps every pond of the simulation.
+// It is only needed when the whole run has to be in memory at the end.
+type HistoryRecorder struct {
+	timePoints []*Pond
+}
+
+// OnStep appends the pond to the history
+func (h *HistoryRecorder) OnStep(gen int, pond *Pond) {
+	h.timePoints = append(h.timePoints, pond)
+}
+
+// TimePoints returns every pond recorded so far, in the order of the generations
+func (h *HistoryRecorder) TimePoints() []*Pond {
+	return h.timePoints
+}
+
+// EndpointRecorder is an observer that only keeps the first and the latest pond of the simulation,
+// which is everything GenerateAnalysis needs.
+type EndpointRecorder struct {
+	first, last *Pond
+	lastGen     int
+}
+
+// OnStep records the first pond it sees and replaces the latest one
+func (e *EndpointRecorder) OnStep(gen int, pond *Pond) {
+	if e.first == nil {
+		e.first = pond
+	}
+	e.last = pond
+	e.lastGen = gen
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// SpatialGrid is a uniform grid laid over the pond that buckets the swimbots and the food bits by position,
+// so a bot looking for a goal only has to look at the cells within its view range instead of the whole pond.
+// Objects outside of the pond are put into the closest border cell, so a query never misses them.
+// In a wrapping pond a query that goes over an edge continues at the opposite edge.
+type SpatialGrid struct {
+	cellSize   float64
+	cols, rows int
+	botCells   [][]int // indices of pond.swimbots, per cell
+	foodCells  [][]int // indices of pond.foodBits, per cell
+	wrap       bool
+	width      float64
+	height     float64
+}
+
+// maxGridCells is the largest number of cells along the longer side of the pond
+const maxGridCells = 64
+
+// NewSpatialGrid takes in a pond and a cell size and buckets every living bot and every remaining food bit of the pond.
+// The grid is built again every generation, so with a tiny cell size it would cost more than scanning the whole pond:
+// the cells are never smaller than the longer side of the pond divided by maxGridCells.
+func NewSpatialGrid(pond *Pond, cellSize float64) *SpatialGrid {
+	cellSize = math.Max(cellSize, math.Max(pond.width, pond.height)/maxGridCells)
+	var grid SpatialGrid
+	grid.cellSize = cellSize
+	grid.wrap = pond.boundary == wrapBoundary
+	grid.width = pond.width
+	grid.height = pond.height
+	if grid.wrap {
+		// every bot of a wrapping pond is inside, and the cells have to end exactly at the edge
+		grid.cols = int(math.Max(1, math.Ceil(pond.width/cellSize)))
+		grid.rows = int(math.Max(1, math.Ceil(pond.height/cellSize)))
+	} else {
+		grid.cols = int(math.Ceil(pond.width/cellSize)) + 1
+		grid.rows = int(math.Ceil(pond.height/cellSize)) + 1
+	}
+	grid.botCells = make([][]int, grid.cols*grid.rows)
+	grid.foodCells = make([][]int, grid.cols*grid.rows)
+
+	// the indices are added in increasing order, so each cell is already sorted
+	for i, b := range pond.swimbots {
+		if b != nil {
+			cell := grid.cellIndex(b.position)
+			grid.botCells[cell] = append(grid.botCells[cell], i)
+		}
+	}
+	for i, f := range pond.foodBits {
+		if f != nil {
+			cell := grid.cellIndex(f.position)
+			grid.foodCells[cell] = append(grid.foodCells[cell], i)
+		}
+	}
+	return &grid
+}
+
+// column and row return the column/row of a coordinate, clamped to the grid
+func (grid *SpatialGrid) column(x float64) int {
+	return clampCell(x/grid.cellSize, grid.cols)
+}
+
+func (grid *SpatialGrid) row(y float64) int {
+	return clampCell(y/grid.cellSize, grid.rows)
+}
+
+// clampCell turns a position measured in cells into a cell number between 0 and n-1
+func clampCell(c float64, n int) int {
+	if math.IsNaN(c) || c < 0 {
+		return 0
+	}
+	if c >= float64(n-1) {
+		return n - 1
+	}
+	return int(c)
+}
+
+// cellIndex returns the index of the cell containing position
+func (grid *SpatialGrid) cellIndex(position OrderedPair) int {
+	return grid.row(position.y)*grid.cols + grid.column(position.x)
+}
+
+// NearbySwimbots returns the indices of all the bots that might be within radius of center, in increasing order.
+// The caller still has to check the actual distance.
+func (grid *SpatialGrid) NearbySwimbots(center OrderedPair, radius float64) []int {
+	return grid.query(grid.botCells, center, radius)
+}
+
+// NearbyFood returns the indices of all the food bits that might be within radius of center, in increasing order.
+// The caller still has to check the actual distance.
+func (grid *SpatialGrid) NearbyFood(center OrderedPair, radius float64) []int {
+	return grid.query(grid.foodCells, center, radius)
+}
+
+// query collects the indices stored in every cell that overlaps the square around center
+func (grid *SpatialGrid) query(cells [][]int, center OrderedPair, radius float64) []int {
+	cols := grid.cellsNear(center.x, radius, grid.width, grid.cols)
+	rows := grid.cellsNear(center.y, radius, grid.height, grid.rows)
+
+	indices := make([]int, 0)
+	for _, r := range rows {
+		for _, c := range cols {
+			indices = append(indices, cells[r*grid.cols+c]...)
+		}
+	}
+	// callers break ties by index, so hand them out in the same order as a full scan would
+	sort.Ints(indices)
+	return indices
+}
+
+// cellsNear returns the cells along one axis of length size that overlap [x-radius, x+radius], every cell once
+func (grid *SpatialGrid) cellsNear(x, radius, size float64, n int) []int {
+	if !grid.wrap {
+		return cellRange(clampCell((x-radius)/grid.cellSize, n), clampCell((x+radius)/grid.cellSize, n))
+	}
+	if 2*radius >= size {
+		return cellRange(0, n-1)
+	}
+	low, high := wrapCoordinate(x-radius, size), wrapCoordinate(x+radius, size)
+	lowCell, highCell := clampCell(low/grid.cellSize, n), clampCell(high/grid.cellSize, n)
+	if low <= high {
+		return cellRange(lowCell, highCell)
+	}
+	// the interval goes over the edge, if both ends fall into the same cell it covers the whole axis
+	if lowCell <= highCell {
+		return cellRange(0, n-1)
+	}
+	return append(cellRange(lowCell, n-1), cellRange(0, highCell)...)
+}
+
+// cellRange returns the cells from first to last
+func cellRange(first, last int) []int {
+	cells := make([]int, 0, last-first+1)
+	for c := first; c <= last; c++ {
+		cells = append(cells, c)
+	}
+	return cells
+}
+
+// SwimbotsNear returns the indices of the bots that might be within radius of center,
+// using the pond's spatial grid when it has one and every index otherwise.
+func (pond *Pond) SwimbotsNear(center OrderedPair, radius float64) []int {
+	if pond.grid != nil {
+		return pond.grid.NearbySwimbots(center, radius)
+	}
+	indices := make([]int, len(pond.swimbots))
+	for i := range indices {
+		indices[i] = i
+	}
+	return indices
+}
+
+// FoodNear returns the indices of the food bits that might be within radius of center,
+// using the pond's spatial grid when it has one and every index otherwise.
+func (pond *Pond) FoodNear(center OrderedPair, radius float64) []int {
+	if pond.grid != nil {
+		return pond.grid.NearbyFood(center, radius)
+	}
+	indices := make([]int, len(pond.foodBits))
+	for i := range indices {
+		indices[i] = i
+	}
+	return indices
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// segmentTraitRanges holds the width of the range of every trait of a segment gene, in the order of the gene
+var segmentTraitRanges = []float64{
+	maxColor - minColor, maxColor - minColor, maxColor - minColor,
+	maxAngleToParent - minAngleToParent,
+	maxSegmentLength - minSegmentLength,
+	maxSegmentWidth - minSegmentWidth,
+	maxOscillationAmplitude - minOscillationAmplitude,
+	maxOscillationPhase - minOscillationPhase,
+	maxAttachment - minAttachment,
+}
+
+// GeneticDistance returns how different the genomes of two bots are, between 0 for identical genomes and 1.
+// Every trait contributes its difference divided by the width of its range, and the result is the average over all traits.
+// A segment gene that only one of the bots has counts as completely different.
+// The behaviour and brain genes don't count, so a threshold means the same with and without them.
+func (bot *Swimbot) GeneticDistance(other *Swimbot) float64 {
+	sum := 0.0
+	sum += math.Abs(bot.botGene.angularMovement-other.botGene.angularMovement) / (maxAngularMovement - minAngularMovement)
+	sum += math.Abs(bot.botGene.translationalMovement-other.botGene.translationalMovement) / (maxTranslationalMovement - minTranslationalMovement)
+	sum += math.Abs(float64(bot.botGene.numSegments-other.botGene.numSegments)) / (maxNumSegments - minNumSegments)
+	sum += math.Abs(bot.botGene.diet-other.botGene.diet) / (maxDiet - minDiet)
+	numTraits := 4
+
+	numGenes := len(bot.segGenes)
+	if len(other.segGenes) > numGenes {
+		numGenes = len(other.segGenes)
+	}
+	for i := 0; i < numGenes; i++ {
+		numTraits += len(segmentTraitRanges)
+		if i >= len(bot.segGenes) || i >= len(other.segGenes) {
+			sum += float64(len(segmentTraitRanges))
+			continue
+		}
+		for k, width := range segmentTraitRanges {
+			sum += math.Abs(bot.segGenes[i][k]-other.segGenes[i][k]) / width
+		}
+	}
+	return sum / float64(numTraits)
+}
+
+// CompatibleWith returns true if the two bots are genetically close enough to mate.
+// A pond without a species threshold lets every bot mate with every other.
+func (bot *Swimbot) CompatibleWith(other *Swimbot, pond *Pond) bool {
+	return pond.speciesThreshold <= 0 || bot.GeneticDistance(other) <= pond.speciesThreshold
+}
+
+// GenomeCopy returns a bot that carries a copy of the genes of bot and nothing else,
+// used as the founder or the representative of a species
+func GenomeCopy(bot *Swimbot) *Swimbot {
+	var genome Swimbot
+	genome.botGene = bot.botGene
+	genome.segGenes = make([]SegmentGene, len(bot.segGenes))
+	for i := range bot.segGenes {
+		genome.segGenes[i] = append(SegmentGene{}, bot.segGenes[i]...)
+	}
+	return &genome
+}
+
+// RandomFounders returns the genomes of numSpecies founders drawn from r
+func RandomFounders(numSpecies int, r *rand.Rand) []*Swimbot {
+	founders := make([]*Swimbot, numSpecies)
+	for i := range founders {
+		var founder Swimbot
+		founder.botGene, founder.segGenes = RandomGenome(r)
+		founders[i] = &founder
+	}
+	return founders
+}
+
+// FounderVariant returns a genome close to the founder's: every continuous trait is moved by a Gaussian
+// with standard deviation spread times the width of the trait's range. The number of segments and the diet stay.
+func FounderVariant(founder *Swimbot, spread float64, r *rand.Rand) (CommonGene, []SegmentGene) {
+	genome := GenomeCopy(founder)
+	var variation MutationConfig
+	variation.color = TraitMutation{1, spread * (maxColor - minColor)}
+	variation.angleToParent = TraitMutation{1, spread * (maxAngleToParent - minAngleToParent)}
+	variation.length = TraitMutation{1, spread * (maxSegmentLength - minSegmentLength)}
+	variation.width = TraitMutation{1, spread * (maxSegmentWidth - minSegmentWidth)}
+	variation.angularMovement = TraitMutation{1, spread * (maxAngularMovement - minAngularMovement)}
+	variation.translationalMovement = TraitMutation{1, spread * (maxTranslationalMovement - minTranslationalMovement)}
+	variation.oscillationAmplitude = TraitMutation{1, spread * (maxOscillationAmplitude - minOscillationAmplitude)}
+	variation.oscillationPhase = TraitMutation{1, spread * (maxOscillationPhase - minOscillationPhase)}
+	variation.attachment = TraitMutation{1, spread * (maxAttachment - minAttachment)}
+	genome.segGenes = MutateGenome(&genome.botGene, genome.segGenes, variation, r)
+	return genome.botGene, genome.segGenes
+}
+
+// Species is a group of bots whose genomes are all close to the genome of its representative
+type Species struct {
+	id             int
+	parent         int // species the first member was assigned to before it split off, -1 for the first species
+	representative *Swimbot
+	birthStep      int
+	extinctionStep int // -1 while the species is alive
+}
+
+// SpeciesEvent is the birth or the extinction of a species
+type SpeciesEvent struct {
+	step    int
+	species int
+	kind    string // "speciation" or "extinction"
+}
+
+// SpeciesTracker is an observer that sorts the living bots into species every interval generations.
+// A bot stays in the species of its mother (or its own species from the last time) as long as
+// its genetic distance to the representative is within the threshold; otherwise it joins the first species
+// close enough, or founds a new one. A species without members is extinct.
+// The oldest member of a species becomes its representative, so a species follows the drift of its genes.
+type SpeciesTracker struct {
+	threshold   float64
+	interval    int
+	species     []*Species
+	assignments map[int]int // species of every bot that was alive at the last sorting
+	counts      [][]int     // step, species and number of members for every living species at every sorting
+	events      []SpeciesEvent
+}
+
+// NewSpeciesTracker returns a tracker that sorts the bots with the given distance threshold every interval generations
+func NewSpeciesTracker(threshold float64, interval int) *SpeciesTracker {
+	var tracker SpeciesTracker
+	tracker.threshold = threshold
+	tracker.interval = interval
+	tracker.assignments = make(map[int]int)
+	return &tracker
+}
+
+// OnStep sorts the bots of the pond into species if gen is a multiple of the interval
+func (tracker *SpeciesTracker) OnStep(gen int, pond *Pond) {
+	if gen%tracker.interval != 0 {
+		return
+	}
+
+	// the bots are sorted by ID, so the oldest bots found the new species
+	bots := make([]*Swimbot, 0, len(pond.swimbots))
+	for _, b := range pond.swimbots {
+		if b != nil {
+			bots = append(bots, b)
+		}
+	}
+	sort.Slice(bots, func(i, j int) bool { return bots[i].id < bots[j].id })
+
+	assignments := make(map[int]int, len(bots))
+	members := make(map[int]int)
+	representatives := make(map[int]*Swimbot)
+	for _, bot := range bots {
+		previous := tracker.previousSpecies(bot, pond, assignments)
+		species := -1
+		if previous != -1 && tracker.fits(bot, previous) {
+			species = previous
+		} else {
+			for _, s := range tracker.species {
+				if tracker.fits(bot, s.id) {
+					species = s.id
+					break
+				}
+			}
+		}
+		if species == -1 {
+			species = tracker.newSpecies(bot, previous, gen)
+		}
+		assignments[bot.id] = species
+		if members[species] == 0 {
+			representatives[species] = bot
+		}
+		members[species]++
+	}
+
+	for _, s := range tracker.species {
+		if s.extinctionStep != -1 {
+			continue
+		}
+		if members[s.id] == 0 {
+			s.extinctionStep = gen
+			tracker.events = append(tracker.events, SpeciesEvent{gen, s.id, "extinction"})
+			continue
+		}
+		s.representative = GenomeCopy(representatives[s.id])
+		tracker.counts = append(tracker.counts, []int{gen, s.id, members[s.id]})
+	}
+	tracker.assignments = assignments
+}
+
+// previousSpecies returns the species the bot belonged to at the last sorting, or the species of its mother
+// for a bot born since then, or -1 if neither is known
+func (tracker *SpeciesTracker) previousSpecies(bot *Swimbot, pond *Pond, assignments map[int]int) int {
+	if species, exists := tracker.assignments[bot.id]; exists {
+		return species
+	}
+	if record := pond.pedigree.Record(bot.id); record != nil && record.mother != -1 {
+		// the mother may have been sorted in this round already
+		if species, exists := assignments[record.mother]; exists {
+			return species
+		}
+		if species, exists := tracker.assignments[record.mother]; exists {
+			return species
+		}
+	}
+	return -1
+}
+
+// fits returns true if the bot is close enough to the representative of the living species
+func (tracker *SpeciesTracker) fits(bot *Swimbot, species int) bool {
+	s := tracker.species[species]
+	return s.extinctionStep == -1 && bot.GeneticDistance(s.representative) <= tracker.threshold
+}
+
+// newSpecies founds a new species with the bot as representative and returns its ID
+func (tracker *SpeciesTracker) newSpecies(bot *Swimbot, parent, gen int) int {
+	var s Species
+	s.id = len(tracker.species)
+	s.parent = parent
+	s.representative = GenomeCopy(bot)
+	s.birthStep = gen
+	s.extinctionStep = -1
+	tracker.species = append(tracker.species, &s)
+	tracker.events = append(tracker.events, SpeciesEvent{gen, s.id, "speciation"})
+	return s.id
+}
+
+// SpeciesOf returns the species the bot was sorted into at the last sorting, or -1 if it wasn't alive then
+func (tracker *SpeciesTracker) SpeciesOf(botID int) int {
+	if species, exists := tracker.assignments[botID]; exists {
+		return species
+	}
+	return -1
+}
+
+// NumLiving returns the number of species that are not extinct
+func (tracker *SpeciesTracker) NumLiving() int {
+	living := 0
+	for _, s := range tracker.species {
+		if s.extinctionStep == -1 {
+			living++
+		}
+	}
+	return living
+}
+
+// Events returns the speciation and extinction events in the order they happened
+func (tracker *SpeciesTracker) Events() []SpeciesEvent {
+	return tracker.events
+}
+
+// WriteSpeciesCSV writes the species into two csv files:
+// filename+"Counts.csv" with the number of members of every living species at every sorting
+// and filename+"Events.csv" with every speciation and extinction, and the parent species of every new species.
+func (tracker *SpeciesTracker) WriteSpeciesCSV(filename string) {
+	counts := make([][]string, 0, len(tracker.counts)+1)
+	counts = append(counts, []string{"step", "species", "members"})
+	for _, c := range tracker.counts {
+		counts = append(counts, []string{strconv.Itoa(c[0]), strconv.Itoa(c[1]), strconv.Itoa(c[2])})
+	}
+
+	events := make([][]string, 0, len(tracker.events)+1)
+	events = append(events, []string{"step", "species", "event", "parent"})
+	for _, e := range tracker.events {
+		events = append(events, []string{strconv.Itoa(e.step), strconv.Itoa(e.species), e.kind, strconv.Itoa(tracker.species[e.species].parent)})
+	}
+
+	WriteRowsToCSV(counts, filename+"Counts")
+	WriteRowsToCSV(events, filename+"Events")
+}
+
+// String summarizes the species of the run
+func (tracker *SpeciesTracker) String() string {
+	speciations, extinctions := 0, 0
+	for _, e := range tracker.events {
+		if e.kind == "speciation" {
+			speciations++
+		} else {
+			extinctions++
+		}
+	}
+	return fmt.Sprintf("%d species arose, %d went extinct and %d are alive", speciations, extinctions, tracker.NumLiving())
+}
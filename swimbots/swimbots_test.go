@@ -2,7 +2,11 @@ package main
 
 import (
 	"fmt"
+	"image"
+	"image/color"
 	"io/ioutil"
+	"math"
+	"math/rand"
 	"os"
 	"strconv"
 	"strings"
@@ -17,6 +21,7 @@ func TestCopySegmentTree(t *testing.T) {
 
 	inputDirectory := "tests/CopySegmentTree/input/"
 	outputDirectory := "tests/CopySegmentTree/output/"
+	SkipWithoutFixtures(t, inputDirectory, outputDirectory)
 
 	inputFiles := ReadFilesFromDirectory(inputDirectory)
 	outputFiles := ReadFilesFromDirectory(outputDirectory)
@@ -139,6 +144,7 @@ func TestUpdateVelocity(t *testing.T) {
 	inputDirectory1 := "tests/UpdateVelocity/input1/"
 	inputDirectory2 := "tests/UpdateVelocity/input2/"
 	outputDirectory := "tests/UpdateVelocity/output/"
+	SkipWithoutFixtures(t, inputDirectory1, inputDirectory2, outputDirectory)
 
 	inputFiles1 := ReadFilesFromDirectory(inputDirectory1)
 	inputFiles2 := ReadFilesFromDirectory(inputDirectory2)
@@ -159,7 +165,7 @@ func TestUpdateVelocity(t *testing.T) {
 	}
 
 	for i, test := range tests {
-		test.inputBot.UpdateVelocity(test.inputPond)
+		test.inputBot.UpdateVelocity(test.inputPond, NewSimulationConfig())
 		//check if the bot's velocity is updated correctly
 		if !SwimbotistheSame(test.inputBot, test.outputBot) {
 			t.Errorf("Error! For input test dataset %d your function failed", i)
@@ -181,6 +187,7 @@ func TestMating(t *testing.T) {
 	inputDirectory1 := "tests/Mating/input1/"
 	inputDirectory2 := "tests/Mating/input2/"
 	outputDirectory := "tests/Mating/output/"
+	SkipWithoutFixtures(t, inputDirectory1, inputDirectory2, outputDirectory)
 
 	inputFiles1 := ReadFilesFromDirectory(inputDirectory1)
 	inputFiles2 := ReadFilesFromDirectory(inputDirectory2)
@@ -201,7 +208,7 @@ func TestMating(t *testing.T) {
 	}
 
 	for i, test := range tests {
-		outcome := test.inputPond.Mating(test.indexS1, test.indexS2, test.indexKid)
+		outcome := test.inputPond.Mating(test.indexS1, test.indexS2, test.indexKid, NewSimulationConfig())
 		//check if the mating function produces a child normally
 		if !ChildbotistheSame(outcome, test.outputBot) {
 			t.Errorf("Error! For input test dataset %d your function failed", i)
@@ -212,6 +219,1331 @@ func TestMating(t *testing.T) {
 
 }
 
+func TestSimulationConfigValidate(t *testing.T) {
+	type test struct {
+		modify  func(config *SimulationConfig)
+		isValid bool
+	}
+
+	tests := []test{
+		{func(config *SimulationConfig) {}, true},
+		{func(config *SimulationConfig) { config.time = 0 }, false},
+		{func(config *SimulationConfig) { config.viewRange = -1 }, false},
+		{func(config *SimulationConfig) { config.foodFrequency = 0 }, false},
+		{func(config *SimulationConfig) { config.matingPreference = "unknown" }, false},
+		{func(config *SimulationConfig) { config.acceptFraction = 2 }, false},
+	}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		test.modify(config)
+		err := config.Validate()
+		//check if Validate accepts the valid configs and rejects the invalid ones
+		if (err == nil) != test.isValid {
+			t.Errorf("Error! For input test dataset %d Validate returned %v, want valid = %v", i, err, test.isValid)
+		}
+	}
+}
+
+func TestNewRandomStreams(t *testing.T) {
+	type test struct {
+		seed1, seed2 int64
+		isSame       bool
+	}
+
+	tests := []test{
+		{0, 0, true},
+		{42, 42, true},
+		{0, 1, false},
+	}
+
+	for i, test := range tests {
+		config1 := NewSimulationConfig()
+		config1.seed = test.seed1
+		config2 := NewSimulationConfig()
+		config2.seed = test.seed2
+		pond1 := InitializePond(config1)
+		pond2 := InitializePond(config2)
+		// drawing extra numbers from one stream must not change the others
+		pond1.rng.spawn.Float64()
+		pond2.rng.choice.Float64()
+		food1, food2 := pond1.rng.food.Int63(), pond2.rng.food.Int63()
+		sameBots := true
+		for j := range pond1.swimbots {
+			if pond1.swimbots[j].position != pond2.swimbots[j].position || pond1.swimbots[j].botGene != pond2.swimbots[j].botGene {
+				sameBots = false
+			}
+		}
+		//check if the same seed gives the same pond and different seeds give different ponds
+		if same := food1 == food2 && sameBots; same != test.isSame {
+			t.Errorf("Error! For input test dataset %d with seeds %d and %d the food streams drew %d and %d and the bots matched = %v, want the same pond = %v", i, test.seed1, test.seed2, food1, food2, sameBots, test.isSame)
+		}
+	}
+}
+
+func TestSimulatorRun(t *testing.T) {
+	type test struct {
+		numGens int
+	}
+
+	tests := []test{{0}, {1}, {20}}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.numGens = test.numGens
+		config.numInitialBots = 20
+		sim := NewSimulator(config)
+		var history HistoryRecorder
+		gens := make([]int, 0)
+		sim.AddObserver(&history)
+		sim.AddObserver(ObserverFunc(func(gen int, pond *Pond) {
+			gens = append(gens, gen)
+		}))
+		sim.Run()
+		//check if the observers see every generation exactly once and in order
+		if len(history.TimePoints()) != test.numGens+1 || len(gens) != test.numGens+1 {
+			t.Errorf("Error! For input test dataset %d the observers saw %d and %d ponds, want %d", i, len(history.TimePoints()), len(gens), test.numGens+1)
+			continue
+		}
+		for gen := range gens {
+			if gens[gen] != gen {
+				t.Errorf("Error! For input test dataset %d the observer saw generation %d in place %d", i, gens[gen], gen)
+			}
+		}
+		if history.TimePoints()[test.numGens] != sim.Pond() {
+			t.Errorf("Error! For input test dataset %d the last recorded pond isn't the pond of the simulator", i)
+		}
+	}
+}
+
+func TestSpatialGridFindNewGoal(t *testing.T) {
+	type test struct {
+		energy           float64
+		matingPreference string
+	}
+
+	tests := []test{{10, "more-segments"}, {100, "faster"}, {100, "similar-length"}}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.numInitialBots = 300
+		config.matingPreference = test.matingPreference
+		pond := InitializePond(config)
+		// move a few bots out of the pond, they must still be found
+		pond.swimbots[0].position.x = -50
+		pond.swimbots[1].position.y = pond.height + 120
+		for _, bot := range pond.swimbots {
+			bot.energy = test.energy
+			pond.grid = nil
+			bruteForce := bot.FindNewGoal(pond, config.viewRange, config.hungerThreshold, config.MatePreference(), pond.rng.BotStream(0, 0))
+			pond.grid = NewSpatialGrid(pond, config.viewRange)
+			indexed := bot.FindNewGoal(pond, config.viewRange, config.hungerThreshold, config.MatePreference(), pond.rng.BotStream(0, 0))
+			//check if the grid gives exactly the same goals as scanning the whole pond
+			if bruteForce != indexed {
+				t.Errorf("Error! For input test dataset %d bot %d got the goal %+v with the grid, want %+v", i, bot.id, indexed, bruteForce)
+			}
+		}
+	}
+}
+
+func TestSpatialGridTinyViewRange(t *testing.T) {
+	type test struct {
+		viewRange float64
+		boundary  string
+	}
+
+	tests := []test{{1, reflectBoundary}, {2, reflectBoundary}, {0.01, wrapBoundary}, {1, wrapBoundary}}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.viewRange = test.viewRange
+		config.boundary = test.boundary
+		pond := InitializePond(config)
+		// a few food bits and bots right next to the first bots, so there is something within the view range
+		for k := 0; k < 10; k++ {
+			pond.foodBits[k].position = OrderedPair{pond.swimbots[2*k].position.x + test.viewRange/2, pond.swimbots[2*k].position.y}
+			pond.swimbots[2*k+1].position = OrderedPair{pond.swimbots[2*k].position.x, pond.swimbots[2*k].position.y - test.viewRange/2}
+		}
+		grid := NewSpatialGrid(pond, config.viewRange)
+		//check if the grid stays small however small the view range is
+		if grid.cols > maxGridCells+1 || grid.rows > maxGridCells+1 {
+			t.Errorf("Error! For input test dataset %d the grid has %d by %d cells, want at most %d by %d", i, grid.cols, grid.rows, maxGridCells+1, maxGridCells+1)
+		}
+		//check if the coarser grid still gives exactly the same goals as scanning the whole pond
+		for k, bot := range pond.swimbots[:20] {
+			bot.energy = 10 * float64(k%2) * config.hungerThreshold
+			pond.grid = nil
+			bruteForce := bot.FindNewGoal(pond, config.viewRange, config.hungerThreshold, config.MatePreference(), pond.rng.BotStream(0, 0))
+			pond.grid = grid
+			indexed := bot.FindNewGoal(pond, config.viewRange, config.hungerThreshold, config.MatePreference(), pond.rng.BotStream(0, 0))
+			if bruteForce != indexed {
+				t.Errorf("Error! For input test dataset %d bot %d got the goal %+v with the grid, want %+v", i, bot.id, indexed, bruteForce)
+			}
+		}
+	}
+}
+
+func TestParallelUpdatePond(t *testing.T) {
+	type test struct {
+		numWorkers int
+	}
+
+	tests := []test{{2}, {4}, {16}}
+
+	for i, test := range tests {
+		serialConfig := NewSimulationConfig()
+		serialConfig.numGens = 60
+		serialConfig.numWorkers = 1
+		parallelConfig := NewSimulationConfig()
+		parallelConfig.numGens = 60
+		parallelConfig.numWorkers = test.numWorkers
+
+		serial := SimulatePond(serialConfig)
+		parallel := SimulatePond(parallelConfig)
+
+		//check if every bot ends up in exactly the same state with any number of workers
+		if len(serial[60].swimbots) != len(parallel[60].swimbots) {
+			t.Errorf("Error! For input test dataset %d the parallel pond has %d slots, want %d", i, len(parallel[60].swimbots), len(serial[60].swimbots))
+			continue
+		}
+		for j := range serial[60].swimbots {
+			bot1, bot2 := serial[60].swimbots[j], parallel[60].swimbots[j]
+			if (bot1 == nil) != (bot2 == nil) || (bot1 != nil && !SwimbotistheSame(bot1, bot2)) {
+				t.Errorf("Error! For input test dataset %d the bot in slot %d differs between the serial and the parallel run", i, j)
+			}
+		}
+	}
+}
+
+func TestSwimbotByID(t *testing.T) {
+	type test struct {
+		numGens int
+	}
+
+	tests := []test{{0}, {50}, {200}}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.numGens = test.numGens
+		timePoints := SimulatePond(config)
+		pond := timePoints[test.numGens]
+		//check if every living bot can be found by its ID and the IDs are never reused
+		seen := make(map[int]bool)
+		for _, bot := range pond.swimbots {
+			if bot == nil {
+				continue
+			}
+			if pond.SwimbotByID(bot.id) != bot {
+				t.Errorf("Error! For input test dataset %d SwimbotByID(%d) didn't return the bot", i, bot.id)
+			}
+			if seen[bot.id] || bot.id >= pond.nextBotID {
+				t.Errorf("Error! For input test dataset %d the ID %d is reused or not below the next ID %d", i, bot.id, pond.nextBotID)
+			}
+			if !bot.RelatedTo(bot.id) {
+				t.Errorf("Error! For input test dataset %d bot %d isn't part of its own family %v", i, bot.id, bot.family)
+			}
+			seen[bot.id] = true
+		}
+		for _, f := range pond.foodBits {
+			if f != nil && pond.FoodByID(f.id) != f {
+				t.Errorf("Error! For input test dataset %d FoodByID(%d) didn't return the food bit", i, f.id)
+			}
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	type test struct {
+		compactionInterval int
+	}
+
+	tests := []test{{1}, {7}, {50}}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.numGens = 150
+		config.compactionInterval = 0
+		uncompacted := SimulatePond(config)[150]
+		config.compactionInterval = test.compactionInterval
+		compacted := SimulatePond(config)[150]
+
+		//check if compaction only removes the tombstones and doesn't change the simulation
+		for _, bot := range uncompacted.swimbots {
+			if bot == nil {
+				continue
+			}
+			other := compacted.SwimbotByID(bot.id)
+			if other == nil || !SwimbotistheSame(bot, other) {
+				t.Errorf("Error! For input test dataset %d bot %d is missing or differs in the compacted pond", i, bot.id)
+			}
+		}
+		if test.compactionInterval == 1 {
+			for j, bot := range compacted.swimbots {
+				if bot == nil {
+					t.Errorf("Error! For input test dataset %d slot %d is still a tombstone after compacting every generation", i, j)
+				}
+			}
+		}
+	}
+}
+
+func TestPedigree(t *testing.T) {
+	type test struct {
+		id1, id2        int
+		ancestors       []int
+		descendants     []int
+		commonAncestors []int
+	}
+
+	// bots 0 to 3 are founders, 4 = 0 x 1, 5 = 2 x 3, 6 = 4 x 5, 7 = 4 x 2
+	pedigree := NewPedigree()
+	for id := 0; id < 4; id++ {
+		pedigree.RecordBirth(id, -1, -1, 0)
+	}
+	pedigree.RecordBirth(4, 0, 1, 3)
+	pedigree.RecordBirth(5, 2, 3, 5)
+	pedigree.RecordBirth(6, 4, 5, 9)
+	pedigree.RecordBirth(7, 4, 2, 12)
+
+	tests := []test{
+		{6, 7, []int{0, 1, 2, 3, 4, 5}, []int{}, []int{0, 1, 2, 4}},
+		{4, 5, []int{0, 1}, []int{6, 7}, []int{}},
+		{2, 7, []int{}, []int{5, 6, 7}, []int{}},
+	}
+
+	for i, test := range tests {
+		//check if the ancestors, descendants and common ancestors are found through every generation
+		if ancestors := pedigree.Ancestors(test.id1); !SliceIsTheSame(ancestors, test.ancestors) {
+			t.Errorf("Error! For input test dataset %d the ancestors of %d are %v, want %v", i, test.id1, ancestors, test.ancestors)
+		}
+		if descendants := pedigree.Descendants(test.id1); !SliceIsTheSame(descendants, test.descendants) {
+			t.Errorf("Error! For input test dataset %d the descendants of %d are %v, want %v", i, test.id1, descendants, test.descendants)
+		}
+		if common := pedigree.CommonAncestors(test.id1, test.id2); !SliceIsTheSame(common, test.commonAncestors) {
+			t.Errorf("Error! For input test dataset %d the common ancestors of %d and %d are %v, want %v", i, test.id1, test.id2, common, test.commonAncestors)
+		}
+	}
+}
+
+func TestMutateGenome(t *testing.T) {
+	type test struct {
+		mutation  MutationConfig
+		unchanged bool
+	}
+
+	always := TraitMutation{1, 1000}
+	enabled := NewMutationConfig()
+	enabled.EnableMutations()
+	tests := []test{
+		{MutationConfig{}, true},
+		{NewMutationConfig(), true},
+		{enabled, false},
+		{MutationConfig{
+			color:                 always,
+			angleToParent:         always,
+			length:                always,
+			width:                 always,
+			angularMovement:       always,
+			translationalMovement: always,
+			oscillationAmplitude:  always,
+			oscillationPhase:      always,
+			attachment:            always,
+			diet:                  always,
+			viewRange:             always,
+			hungerThreshold:       always,
+			brain:                 always,
+			numSegments:           1,
+			duplication:           1,
+			deletion:              1,
+			preference:            1,
+		}, false},
+	}
+
+	for i, test := range tests {
+		pond := InitializePond(NewSimulationConfig())
+		for _, bot := range pond.swimbots {
+			common := bot.botGene
+			segGenes := make([]SegmentGene, len(bot.segGenes))
+			for k := range segGenes {
+				segGenes[k] = append(SegmentGene{}, bot.segGenes[k]...)
+			}
+			segGenes = MutateGenome(&common, segGenes, test.mutation, pond.rng.genome)
+			//check if the mutated genome stays within the ranges of RandomGenome, and doesn't change without mutations
+			if test.unchanged && (common != bot.botGene || segGenes[3][4] != bot.segGenes[3][4]) {
+				t.Errorf("Error! For input test dataset %d bot %d changed without mutations, %+v became %+v", i, bot.id, bot.botGene, common)
+				break
+			}
+			if common.numSegments < minNumSegments || common.numSegments > len(segGenes) || len(segGenes) > maxGenomeLength || common.translationalMovement < minTranslationalMovement || common.angularMovement > maxAngularMovement || common.diet < minDiet || common.diet > maxDiet || common.viewRange > maxViewRange || common.preference < 0 || common.preference >= len(matePreferences) {
+				t.Errorf("Error! For input test dataset %d bot %d mutated out of range to %+v with %d segment genes", i, bot.id, common, len(segGenes))
+				break
+			}
+			for k, gene := range segGenes {
+				if gene[0] < minColor || gene[2] > maxColor || gene[3] < minAngleToParent || gene[4] > maxSegmentLength || gene[5] < minSegmentWidth {
+					t.Errorf("Error! For input test dataset %d segment gene %d of bot %d mutated out of range to %v", i, k, bot.id, gene)
+				}
+			}
+		}
+	}
+}
+
+func TestChooseMate(t *testing.T) {
+	type test struct {
+		preference  MatePreference
+		numSegments []int
+		answer      int
+	}
+
+	lookup := func(name string) MatePreference {
+		preference, err := LookupMatePreference(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return preference
+	}
+	// a custom preference that isn't registered, so it doesn't leak into the registry: prefer the candidate with the lowest ID
+	lowestID := MatePreferenceFunc(func(chooser, candidate *Swimbot, pond *Pond, r *rand.Rand) float64 {
+		return -float64(candidate.id)
+	})
+
+	tests := []test{
+		{lookup("more-segments"), []int{3, 7, 2, 7}, 3},
+		{lookup("fewer-segments"), []int{3, 7, 2, 7}, 2},
+		{lookup("similar-segments"), []int{3, 7, 5, 2}, 2},
+		{lowestID, []int{3, 7, 5, 2}, 0},
+	}
+
+	for i, test := range tests {
+		pond := InitializePond(NewSimulationConfig())
+		chooser := pond.swimbots[0]
+		chooser.botGene.numSegments = 5
+		candidates := make([]*Swimbot, len(test.numSegments))
+		for k := range candidates {
+			candidates[k] = pond.swimbots[k+1]
+			candidates[k].botGene.numSegments = test.numSegments[k]
+		}
+		//check if the preference picks the expected candidate, the last one among equally good candidates
+		if chosen := ChooseMate(test.preference, chooser, candidates, pond, pond.rng.BotStream(0, 0)); chosen != test.answer {
+			t.Errorf("Error! For input test dataset %d the chooser picked candidate %d, want %d", i, chosen, test.answer)
+		}
+	}
+}
+
+func TestAcceptsSuitor(t *testing.T) {
+	type test struct {
+		suitor         int
+		acceptFraction float64
+		answer         bool
+	}
+
+	// the courted bot sees four bots with 2, 4, 6 and 8 segments and prefers more segments
+	tests := []test{{3, 0.5, true}, {2, 0.5, true}, {1, 0.5, false}, {0, 1, true}, {3, 0, false}}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.numInitialBots = 5
+		config.matingPreference = "more-segments"
+		pond := InitializePond(config)
+		target := pond.swimbots[4]
+		target.position = OrderedPair{-10000, -10000}
+		candidates := pond.swimbots[:4]
+		for k, bot := range candidates {
+			bot.botGene.numSegments = 2 * (k + 1)
+			bot.position = OrderedPair{-10000 + float64(k), -10000}
+		}
+		suitor := candidates[test.suitor]
+		if accepted := target.AcceptsSuitor(suitor, pond, config.viewRange, test.acceptFraction, config.MatePreference(), pond.rng.choice); accepted != test.answer {
+			t.Errorf("Error! For input test dataset %d the suitor was accepted = %v, want %v", i, accepted, test.answer)
+		}
+
+		// a rejected bot pays for it and stops courting the bot that rejected it
+		energy := suitor.energy
+		suitor.Reject(target.id, config.rejectionCost)
+		if suitor.rejections != 1 || suitor.energy != energy-config.rejectionCost || suitor.goal.id != -1 {
+			t.Errorf("Error! For input test dataset %d the rejected suitor has %d rejections, energy %v and goal %d, want 1, %v and -1", i, suitor.rejections, suitor.energy, suitor.goal.id, energy-config.rejectionCost)
+		}
+		if containsSwimbot(suitor.SuitableMates(pond, config.viewRange), target) {
+			t.Errorf("Error! For input test dataset %d the suitor still courts the bot that rejected it", i)
+		}
+		// the suitor forgets the rejection after rejectionMemory generations, and right away once it mates
+		suitor.age += rejectionMemory - 1
+		suitor.ForgetRejections()
+		if !suitor.RejectedBy(target.id) {
+			t.Errorf("Error! For input test dataset %d the suitor forgot the rejection after %v generations", i, rejectionMemory-1)
+		}
+		suitor.age++
+		suitor.ForgetRejections()
+		if suitor.RejectedBy(target.id) || !containsSwimbot(suitor.SuitableMates(pond, config.viewRange), target) {
+			t.Errorf("Error! For input test dataset %d the suitor still remembers the rejection after %v generations", i, rejectionMemory)
+		}
+		suitor.Reject(target.id, config.rejectionCost)
+		pond.Mating(test.suitor, 4, pond.nextBotID, config)
+		if len(suitor.rejectedBy) != 0 {
+			t.Errorf("Error! For input test dataset %d the suitor remembers %d rejections after mating, want 0", i, len(suitor.rejectedBy))
+		}
+	}
+}
+
+func TestLocomotion(t *testing.T) {
+	type test struct {
+		locomotion     string
+		angle          float64
+		amplitude      float64
+		speed, turning float64
+	}
+
+	// a bot with a main segment and one segment attached to it, both 10 long and 1 wide, at age 0 and phase 0
+	tests := []test{
+		{genesLocomotion, math.Pi / 2, 0.4, 3, 0.5},
+		{morphologyLocomotion, math.Pi / 2, 0.4, 5, 0},
+		{morphologyLocomotion, 0, 0.4, minTranslationalMovement, 0.4},
+		{morphologyLocomotion, math.Pi / 6, 0.4, 2.5, 0.4 * math.Cos(math.Pi/6)},
+		{morphologyLocomotion, math.Pi / 2, 0, minTranslationalMovement, 0},
+	}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.locomotion = test.locomotion
+		pond := InitializePond(config)
+		bot := pond.swimbots[0]
+		bot.botGene = CommonGene{angularMovement: 0.5, translationalMovement: 3, numSegments: 2}
+		for _, gene := range bot.segGenes {
+			gene[4], gene[5], gene[7] = 10, 1, 0
+		}
+		bot.segGenes[1][3] = test.angle
+		bot.segGenes[1][6] = test.amplitude
+		bot.BuildSegments()
+		speed, turning := bot.Locomotion(config)
+		//check if the speed and the turning follow from the body
+		if math.Abs(speed-test.speed) > 1e-9 || math.Abs(turning-test.turning) > 1e-9 {
+			t.Errorf("Error! For input test dataset %d the speed and turning are %v and %v, want %v and %v", i, speed, turning, test.speed, test.turning)
+		}
+	}
+}
+
+func TestApplyBoundary(t *testing.T) {
+	type test struct {
+		boundary         string
+		position         OrderedPair
+		velocity         OrderedPair
+		answerPosition   OrderedPair
+		answerVelocity   OrderedPair
+		answerAlive      bool
+		answerDistanceTo float64 // distance to the point (5990, 3000)
+	}
+
+	tests := []test{
+		{reflectBoundary, OrderedPair{-5, 6010}, OrderedPair{-5, 10}, OrderedPair{5, 5990}, OrderedPair{5, -10}, true, 6690.315762353822},
+		{wrapBoundary, OrderedPair{-5, 6010}, OrderedPair{-5, 10}, OrderedPair{5995, 10}, OrderedPair{-5, 10}, true, 2990.004180599084},
+		{wrapBoundary, OrderedPair{20, 3000}, OrderedPair{-5, 10}, OrderedPair{20, 3000}, OrderedPair{-5, 10}, true, 30},
+		{absorbBoundary, OrderedPair{-5, 3000}, OrderedPair{-5, 10}, OrderedPair{-5, 3000}, OrderedPair{-5, 10}, false, 5995},
+		{absorbBoundary, OrderedPair{20, 3000}, OrderedPair{-5, 10}, OrderedPair{20, 3000}, OrderedPair{-5, 10}, true, 5970},
+	}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.boundary = test.boundary
+		pond := InitializePond(config)
+		bot := pond.swimbots[0]
+		bot.position, bot.velocity = test.position, test.velocity
+		alive := pond.ApplyBoundary(bot)
+		distance := pond.Distance(bot.position, OrderedPair{5990, 3000})
+		//check if the bot ends up at the right place and the distance takes the boundary into account
+		if alive != test.answerAlive || bot.position != test.answerPosition || bot.velocity != test.answerVelocity {
+			t.Errorf("Error! For input test dataset %d the bot is alive = %v at %v with velocity %v, want %v at %v with velocity %v", i, alive, bot.position, bot.velocity, test.answerAlive, test.answerPosition, test.answerVelocity)
+		}
+		if alive && bot.mainSegment.position != bot.position {
+			t.Errorf("Error! For input test dataset %d the main segment is at %v, want the bot's position %v", i, bot.mainSegment.position, bot.position)
+		}
+		if math.Abs(distance-test.answerDistanceTo) > 1e-9 {
+			t.Errorf("Error! For input test dataset %d the distance is %v, want %v", i, distance, test.answerDistanceTo)
+		}
+	}
+}
+
+func TestWrapSpatialGrid(t *testing.T) {
+	type test struct {
+		viewRange float64
+		energy    float64
+	}
+
+	tests := []test{{300, 10}, {300, 100}, {700, 100}, {4000, 100}}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.numInitialBots = 300
+		config.boundary = wrapBoundary
+		config.viewRange = test.viewRange
+		pond := InitializePond(config)
+		// put bots and food close to the edges and corners, where they can only see each other across the edge
+		for k := 0; k < 40; k++ {
+			pond.swimbots[k].position = OrderedPair{float64(k%2) * (pond.width - float64(k)), float64(k%3) * (pond.height - float64(k)) / 2}
+			pond.foodBits[k].position = OrderedPair{pond.width - float64(k), float64(k)}
+		}
+		for _, bot := range pond.swimbots {
+			bot.energy = test.energy
+			pond.grid = nil
+			bruteForce := bot.FindNewGoal(pond, config.viewRange, config.hungerThreshold, config.MatePreference(), pond.rng.BotStream(0, 0))
+			pond.grid = NewSpatialGrid(pond, config.viewRange)
+			indexed := bot.FindNewGoal(pond, config.viewRange, config.hungerThreshold, config.MatePreference(), pond.rng.BotStream(0, 0))
+			//check if the grid of a wrapping pond gives exactly the same goals as scanning the whole pond
+			if bruteForce != indexed {
+				t.Errorf("Error! For input test dataset %d bot %d got the goal %+v with the grid, want %+v", i, bot.id, indexed, bruteForce)
+			}
+		}
+	}
+}
+
+func TestRectangularPond(t *testing.T) {
+	type test struct {
+		width, height float64
+		boundary      string
+		canvasHeight  int
+	}
+
+	tests := []test{{6000, 6000, reflectBoundary, 1200}, {9000, 1500, reflectBoundary, 200}, {9000, 1500, wrapBoundary, 200}, {1200, 4800, wrapBoundary, 4800}}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.width, config.height = test.width, test.height
+		config.boundary = test.boundary
+		config.viewRange = 400
+		pond := InitializePond(config)
+		// the bots start in the middle two thirds, the food in all but the outer twelfth
+		for _, bot := range pond.swimbots {
+			if bot.position.x < test.width/6 || bot.position.x >= test.width*5/6 || bot.position.y < test.height/6 || bot.position.y >= test.height*5/6 {
+				t.Errorf("Error! For input test dataset %d bot %d starts at %v, outside the middle two thirds", i, bot.id, bot.position)
+			}
+		}
+		for _, f := range pond.foodBits {
+			if f.position.x < test.width/12 || f.position.x >= test.width*11/12 || f.position.y < test.height/12 || f.position.y >= test.height*11/12 {
+				t.Errorf("Error! For input test dataset %d food bit %d lies at %v, in the outer twelfth", i, f.id, f.position)
+			}
+		}
+		// the grid has to find the same goals as a full scan, also across the edges of a wrapping pond
+		pond.swimbots[0].position = OrderedPair{10, test.height - 10}
+		pond.swimbots[1].position = OrderedPair{test.width - 10, 10}
+		for _, bot := range pond.swimbots {
+			bot.energy = 100
+			pond.grid = nil
+			bruteForce := bot.FindNewGoal(pond, config.viewRange, config.hungerThreshold, config.MatePreference(), pond.rng.BotStream(0, 0))
+			pond.grid = NewSpatialGrid(pond, config.viewRange)
+			indexed := bot.FindNewGoal(pond, config.viewRange, config.hungerThreshold, config.MatePreference(), pond.rng.BotStream(0, 0))
+			if bruteForce != indexed {
+				t.Errorf("Error! For input test dataset %d bot %d got the goal %+v with the grid, want %+v", i, bot.id, indexed, bruteForce)
+			}
+		}
+		//check if the frames have the proportions of the pond
+		if height := pond.DrawToCanvas(1200, 10).Bounds().Dy(); height != test.canvasHeight {
+			t.Errorf("Error! For input test dataset %d the frame is %d high, want %d", i, height, test.canvasHeight)
+		}
+	}
+}
+
+func TestObstacles(t *testing.T) {
+	type test struct {
+		line         string
+		a, b         OrderedPair
+		answerBlocks bool // also tells whether a bot at a swimming to b in one step bounces off
+	}
+
+	tests := []test{
+		{"circle 100 100 20", OrderedPair{50, 100}, OrderedPair{150, 100}, true},
+		{"circle 100 100 20", OrderedPair{50, 130}, OrderedPair{150, 130}, false},
+		{"circle 100 100 20", OrderedPair{75, 100}, OrderedPair{85, 100}, true},
+		{"rect 80 0 120 200", OrderedPair{50, 100}, OrderedPair{150, 100}, true},
+		{"rect 120 200 80 0", OrderedPair{50, 250}, OrderedPair{150, 250}, false},
+		{"rect 80 0 120 200", OrderedPair{75, 100}, OrderedPair{85, 100}, true},
+		{"polyline 4 100 0 100 90 200 90", OrderedPair{50, 100}, OrderedPair{150, 100}, false},
+		{"polyline 4 100 0 100 90 200 90", OrderedPair{150, 50}, OrderedPair{150, 150}, true},
+		{"polyline 4 100 0 100 90 200 90", OrderedPair{95, 50}, OrderedPair{105, 50}, true},
+	}
+
+	for i, test := range tests {
+		// write the obstacle into a file and read it back
+		filename := "obstacles_test.txt"
+		if err := ioutil.WriteFile(filename, []byte("# a test obstacle\n\n"+test.line+"\n"), 0644); err != nil {
+			panic(err)
+		}
+		obstacles, err := ReadObstacles(filename)
+		os.Remove(filename)
+
+		config := NewSimulationConfig()
+		config.obstacles = obstacles
+		pond := InitializePond(config)
+		bot := pond.swimbots[0]
+		bot.position = test.a
+		bot.velocity = OrderedPair{test.b.x - test.a.x, test.b.y - test.a.y}
+		bot.UpdatePosition(1, pond)
+		bounced := bot.position == test.a && bot.velocity != OrderedPair{test.b.x - test.a.x, test.b.y - test.a.y}
+		//check if the obstacle blocks movement and sight and bounces the bot back
+		if err != nil || len(obstacles) != 1 {
+			t.Errorf("Error! For input test dataset %d reading %q gave %d obstacles and the error %v, want 1 obstacle", i, test.line, len(obstacles), err)
+		}
+		if sight := pond.LineOfSight(test.a, test.b); sight == test.answerBlocks {
+			t.Errorf("Error! For input test dataset %d the line of sight from %v to %v is %v, want %v", i, test.a, test.b, sight, !test.answerBlocks)
+		}
+		if bounced != test.answerBlocks {
+			t.Errorf("Error! For input test dataset %d the bot bounced = %v, want %v", i, bounced, test.answerBlocks)
+		}
+	}
+
+	// a broken line is reported
+	if _, err := NewObstacle("circle", []float64{1, 2}); err == nil {
+		t.Errorf("Error! A circle without radius was accepted")
+	}
+}
+
+func TestConcaveObstacle(t *testing.T) {
+	type test struct {
+		velocity OrderedPair
+	}
+
+	// a bot in the inner corner of an L-shaped wall, the vertical piece is the closest but the horizontal one is in the way
+	tests := []test{{OrderedPair{0, 10}}, {OrderedPair{1, 10}}, {OrderedPair{-3, 10}}}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.obstacles = []Obstacle{&PolylineObstacle{[]OrderedPair{{102, 50}, {102, 108}, {50, 108}}, 2}}
+		pond := InitializePond(config)
+		bot := pond.swimbots[0]
+		bot.position = OrderedPair{100, 100}
+		bot.velocity = test.velocity
+		bot.UpdatePosition(1, pond)
+		//check if the bot turns away from the wall instead of running into it again
+		if bot.position != (OrderedPair{100, 100}) || bot.velocity.y >= 0 {
+			t.Errorf("Error! For input test dataset %d the bot is at %v with velocity %v, want it at (100, 100) swimming away from the wall", i, bot.position, bot.velocity)
+		}
+		// it may graze the other piece of the wall on the way out, but it doesn't stay in the corner
+		for n := 0; n < 3 && bot.position == (OrderedPair{100, 100}); n++ {
+			bot.UpdatePosition(1, pond)
+		}
+		if bot.position == (OrderedPair{100, 100}) {
+			t.Errorf("Error! For input test dataset %d the bot is still stuck in the corner with velocity %v", i, bot.velocity)
+		}
+	}
+}
+
+func TestWrappedLineOfSight(t *testing.T) {
+	type test struct {
+		obstacle     Obstacle
+		a, b         OrderedPair
+		answerBlocks bool
+	}
+
+	// a wrapping pond of 1000 by 1000, the line takes the shortest way across the edges
+	tests := []test{
+		{&CircleObstacle{OrderedPair{10, 500}, 5}, OrderedPair{950, 500}, OrderedPair{50, 500}, true},
+		{&CircleObstacle{OrderedPair{990, 500}, 5}, OrderedPair{50, 500}, OrderedPair{950, 500}, true},
+		{&CircleObstacle{OrderedPair{500, 500}, 5}, OrderedPair{950, 500}, OrderedPair{50, 500}, false},
+		{&RectObstacle{OrderedPair{400, 0}, OrderedPair{600, 10}}, OrderedPair{500, 950}, OrderedPair{500, 50}, true},
+		{&CircleObstacle{OrderedPair{0, 0}, 5}, OrderedPair{980, 980}, OrderedPair{20, 20}, true},
+		{&CircleObstacle{OrderedPair{0, 0}, 5}, OrderedPair{980, 20}, OrderedPair{20, 980}, true},
+	}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.boundary = wrapBoundary
+		config.width, config.height = 1000, 1000
+		config.obstacles = []Obstacle{test.obstacle}
+		pond := InitializePond(config)
+		//check if sight is blocked along the wrapped line in both directions
+		for _, line := range [][2]OrderedPair{{test.a, test.b}, {test.b, test.a}} {
+			if sight := pond.LineOfSight(line[0], line[1]); sight == test.answerBlocks {
+				t.Errorf("Error! For input test dataset %d the line of sight from %v to %v is %v, want %v", i, line[0], line[1], sight, !test.answerBlocks)
+			}
+		}
+	}
+}
+
+func TestFoodModels(t *testing.T) {
+	type test struct {
+		model   FoodModel
+		xMax    float64 // no food bit may lie to the right of xMax
+		minFood int     // bounds of the number of food bits after 200 generations without bots
+		maxFood int
+	}
+
+	// the left half of the density image is white, the right half black
+	img := image.NewGray(image.Rect(0, 0, 4, 2))
+	for x := 0; x < 2; x++ {
+		for y := 0; y < 2; y++ {
+			img.SetGray(x, y, color.Gray{255})
+		}
+	}
+	patches := []OrderedPair{{1000, 1000}, {2000, 1000}}
+
+	tests := []test{
+		{NewUniformFood(400, 5, 5), 6000, 600, 600},
+		{NewPatchFood(patches, 50, 100, 5, 5), 2500, 300, 300},
+		{NewLogisticFood(1000, 4, 0.05, 0.01, 1), 6000, 36, 144},
+		{NewSeasonalFood(0, 10, 1, 100, 1), 6000, 1800, 2200},
+		{NewDensityImageFood(img, 100, 5, 5), 3000, 300, 300},
+	}
+
+	for i, test := range tests {
+		counts := make([]int, 2)
+		for run := range counts {
+			config := NewSimulationConfig()
+			config.numInitialBots = 0
+			config.foodModel = test.model
+			pond := InitializePond(config)
+			for gen := 1; gen <= 200; gen++ {
+				pond.AddFood(gen, config)
+			}
+			for _, f := range pond.foodBits {
+				if !pond.FreePosition(f.position) || f.position.x > test.xMax {
+					t.Errorf("Error! For input test dataset %d food bit %d lies at %v, not free or right of %v", i, f.id, f.position, test.xMax)
+					break
+				}
+			}
+			counts[run] = len(pond.foodBits)
+		}
+		// the same seed has to give the same food
+		if counts[0] != counts[1] {
+			t.Errorf("Error! For input test dataset %d two runs with the same seed gave %d and %d food bits", i, counts[0], counts[1])
+		}
+		if counts[0] < test.minFood || counts[0] > test.maxFood {
+			t.Errorf("Error! For input test dataset %d there are %d food bits, want between %d and %d", i, counts[0], test.minFood, test.maxFood)
+		}
+	}
+}
+
+func TestFoodTypes(t *testing.T) {
+	type test struct {
+		width        float64 // of the main segment
+		numSegments  int
+		foodType     int // index into the types of the file
+		answerEnergy float64
+	}
+
+	lines := "# name energy weight size red green blue rules\nalgae 30 3 1 0 200 0 maxWidth 2\nshrimp 120 1 2 255 120 0 minWidth 2.5 minSegments 3\n"
+	tests := []test{{1, 2, 0, 30}, {3, 2, 0, 0}, {3, 4, 1, 120}, {3, 2, 1, 0}, {1, 4, 1, 0}}
+
+	for i, test := range tests {
+		// write the food types into a file and read them back
+		filename := "foodtypes_test.txt"
+		if err := ioutil.WriteFile(filename, []byte(lines), 0644); err != nil {
+			panic(err)
+		}
+		foodTypes, err := ReadFoodTypes(filename)
+		os.Remove(filename)
+
+		config := NewSimulationConfig()
+		config.numInitialBots = 1
+		config.foodModel = NewUniformFood(0, 0, 1)
+		config.foodTypes = foodTypes
+		pond := InitializePond(config)
+		bot := pond.swimbots[0]
+		bot.segGenes[0][5] = test.width
+		bot.botGene.numSegments = test.numSegments
+		bot.energy = 10
+		var f Food
+		f.position = OrderedPair{bot.position.x + 5, bot.position.y}
+		f.foodType = foodTypes[test.foodType]
+		pond.AddFoodBit(&f)
+		//check if the bot only goes for and eats the food it can eat, and gets the energy of its type
+		bot.goal = bot.FindNewGoal(pond, config.viewRange, config.hungerThreshold, config.MatePreference(), pond.rng.BotStream(0, 0))
+		pond.EatOrMate(1, config)
+		if err != nil || len(foodTypes) != 2 {
+			t.Errorf("Error! For input test dataset %d reading the file gave %d food types and the error %v, want 2 food types", i, len(foodTypes), err)
+		}
+		if gained := bot.energy - 10; gained != test.answerEnergy {
+			t.Errorf("Error! For input test dataset %d the bot gained %v energy, want %v", i, gained, test.answerEnergy)
+		}
+	}
+}
+
+func TestPredation(t *testing.T) {
+	type test struct {
+		attackTrait                    string
+		predatorWidth, preyWidth       float64 // of every segment
+		predatorSegments, preySegments int
+		answerKilled                   bool
+	}
+
+	tests := []test{
+		{sizeAttack, 3, 1, 2, 2, true},
+		{sizeAttack, 1, 3, 2, 2, false},
+		{sizeAttack, 2, 2, 2, 2, false},
+		{segmentsAttack, 1, 3, 5, 2, true},
+		{segmentsAttack, 3, 1, 2, 5, false},
+	}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.predation = true
+		config.attackTrait = test.attackTrait
+		config.numInitialBots = 2
+		config.foodModel = NewUniformFood(0, 0, 1)
+		pond := InitializePond(config)
+		predator, prey := pond.swimbots[0], pond.swimbots[1]
+		predator.botGene.diet = maxDiet
+		prey.botGene.diet = minDiet
+		for k := range predator.segGenes {
+			predator.segGenes[k][5] = test.predatorWidth
+			prey.segGenes[k][5] = test.preyWidth
+		}
+		predator.botGene.numSegments = test.predatorSegments
+		prey.botGene.numSegments = test.preySegments
+		predator.energy = 10
+		prey.energy = 40
+		prey.position = OrderedPair{predator.position.x + 5, predator.position.y}
+		//check if a hungry predator hunts the prey, and kills it only if it is stronger
+		predator.goal = predator.FindNewGoal(pond, config.viewRange, config.hungerThreshold, config.MatePreference(), pond.rng.BotStream(0, 0))
+		hunts := predator.goal.isPrey && predator.goal.id == prey.id
+		pond.EatOrMate(1, config)
+		killed := pond.SwimbotByID(prey.id) == nil
+		answerEnergy := 10 - config.attackCost
+		if test.answerKilled {
+			answerEnergy = 10 + config.predationEfficiency*40
+		}
+		if !hunts {
+			t.Errorf("Error! For input test dataset %d the predator's goal is %+v, want to hunt bot %d", i, predator.goal, prey.id)
+		}
+		if killed != test.answerKilled || predator.energy != answerEnergy {
+			t.Errorf("Error! For input test dataset %d the prey was killed = %v and the predator has %v energy, want %v and %v", i, killed, predator.energy, test.answerKilled, answerEnergy)
+		}
+	}
+}
+
+func TestSpecies(t *testing.T) {
+	type test struct {
+		numSpecies    int
+		threshold     float64
+		answerSpecies int
+	}
+
+	tests := []test{{2, 0.15, 2}, {4, 0.15, 4}, {1, 0.15, 200}}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.numSpecies = test.numSpecies
+		config.founderSpread = 0.01
+		config.speciesThreshold = test.threshold
+		config.viewRange = 2000
+		pond := InitializePond(config)
+		tracker := NewSpeciesTracker(test.threshold, 1)
+		tracker.OnStep(0, pond)
+		if tracker.NumLiving() != test.answerSpecies || len(tracker.Events()) != test.answerSpecies {
+			t.Errorf("Error! For input test dataset %d the tracker found %d species with %d events, want %d", i, tracker.NumLiving(), len(tracker.Events()), test.answerSpecies)
+		}
+		//check if the bots only find mates of their own species
+		for _, bot := range pond.swimbots[:20] {
+			if distance := bot.GeneticDistance(bot); distance != 0 {
+				t.Errorf("Error! For input test dataset %d bot %d has the genetic distance %v to itself", i, bot.id, distance)
+			}
+			for _, mate := range bot.SuitableMates(pond, config.viewRange) {
+				if tracker.SpeciesOf(mate.id) != tracker.SpeciesOf(bot.id) {
+					t.Errorf("Error! For input test dataset %d bot %d of species %d may mate with bot %d of species %d", i, bot.id, tracker.SpeciesOf(bot.id), mate.id, tracker.SpeciesOf(mate.id))
+				}
+			}
+		}
+		// a species without members goes extinct
+		pond.swimbots[0] = nil
+		if test.numSpecies == 1 {
+			tracker.OnStep(1, pond)
+			if tracker.NumLiving() != test.answerSpecies-1 {
+				t.Errorf("Error! For input test dataset %d %d species are left after a bot died, want %d", i, tracker.NumLiving(), test.answerSpecies-1)
+			}
+		}
+	}
+}
+
+func TestEnergyModel(t *testing.T) {
+	type test struct {
+		massModel     string
+		basal, turn   float64 // basal metabolism and turning cost
+		before, after OrderedPair
+		answerTurn    float64
+	}
+
+	tests := []test{
+		{segmentsMass, 0, 0, OrderedPair{1, 0}, OrderedPair{1, 0}, 0},
+		{segmentsMass, 0.1, 0, OrderedPair{0, 0}, OrderedPair{0, 0}, 0},
+		{areaMass, 0.1, 0.5, OrderedPair{1, 0}, OrderedPair{0, 2}, math.Pi / 2},
+		{areaMass, 0, 0.5, OrderedPair{3, 0}, OrderedPair{-1, 0}, math.Pi},
+	}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.massModel = test.massModel
+		config.basalMetabolism = test.basal
+		config.turningCost = test.turn
+		config.reproductionCost = 3
+		pond := InitializePond(config)
+		bot := pond.swimbots[0]
+		answerMass := config.segmentMass * float64(bot.botGene.numSegments)
+		if test.massModel == areaMass {
+			answerMass = config.segmentMass * bot.BodyArea() / meanSegmentArea
+		}
+		//check if the bot pays for swimming, turning and staying alive in proportion to its mass
+		turn := turnAngle(test.before, test.after)
+		speed := math.Sqrt(test.after.x*test.after.x + test.after.y*test.after.y)
+		energy := bot.energy
+		bot.SpendEnergy(speed, turn, config)
+		answerLoss := (config.energyLossFactor*speed*speed + test.turn*test.answerTurn + test.basal) * answerMass
+		// a bot without a goal keeps swimming straight and only pays for its speed and its metabolism
+		other := pond.swimbots[1]
+		other.goal.id = -1
+		otherEnergy := other.energy
+		other.UpdateVelocity(pond, config)
+		otherSpeed := math.Sqrt(other.velocity.x*other.velocity.x + other.velocity.y*other.velocity.y)
+		answerOtherLoss := (config.energyLossFactor*otherSpeed*otherSpeed + test.basal) * other.mass
+		if bot.mass != answerMass {
+			t.Errorf("Error! For input test dataset %d the mass is %v, want %v", i, bot.mass, answerMass)
+		}
+		if math.Abs(turn-test.answerTurn) > 1e-12 {
+			t.Errorf("Error! For input test dataset %d the turn is %v, want %v", i, turn, test.answerTurn)
+		}
+		if loss := energy - bot.energy; math.Abs(loss-answerLoss) > 1e-9 {
+			t.Errorf("Error! For input test dataset %d the bot lost %v energy, want %v", i, loss, answerLoss)
+		}
+		if loss := otherEnergy - other.energy; math.Abs(loss-answerOtherLoss) > 1e-9 {
+			t.Errorf("Error! For input test dataset %d the bot without a goal lost %v energy, want %v", i, loss, answerOtherLoss)
+		}
+		// giving birth costs both parents the reproduction cost, on top of the half they give the child
+		energy, otherEnergy = bot.energy, other.energy
+		pond.Mating(0, 1, pond.nextBotID, config)
+		if bot.energy != energy*0.5-config.reproductionCost || other.energy != otherEnergy*0.5-config.reproductionCost {
+			t.Errorf("Error! For input test dataset %d the parents have %v and %v energy after the birth, want %v and %v", i, bot.energy, other.energy, energy*0.5-config.reproductionCost, otherEnergy*0.5-config.reproductionCost)
+		}
+	}
+}
+
+func TestHeritableTopology(t *testing.T) {
+	type test struct {
+		attachments   []float64 // attachment genes of the segments 1, 2 and 3
+		answerParents []int     // index of the segment each of them is attached to
+	}
+
+	tests := []test{
+		{[]float64{0, 0, 0}, []int{0, 0, 0}},
+		{[]float64{0.5, 0.99, 0.99}, []int{0, 1, 2}},
+		{[]float64{1, 0.5, 0.5}, []int{0, 1, 1}},
+		{[]float64{0.3, 0.2, 0.7}, []int{0, 0, 2}},
+	}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.mutation = MutationConfig{}
+		pond := InitializePond(config)
+		// two parents with the same genome
+		for _, bot := range pond.swimbots[:2] {
+			bot.botGene.numSegments = 4
+			for k, attachment := range test.attachments {
+				bot.segGenes[k+1] = append(SegmentGene{}, pond.swimbots[0].segGenes[k+1]...)
+				bot.segGenes[k+1][8] = attachment
+			}
+			bot.segGenes[0] = append(SegmentGene{}, pond.swimbots[0].segGenes[0]...)
+			bot.BuildSegments()
+		}
+		child := pond.Mating(0, 1, pond.nextBotID, config)
+		//check if the segments attach where their genes say, in the parents and in the child
+		for _, bot := range []*Swimbot{pond.swimbots[0], child} {
+			parents := make(map[int]int)
+			for _, seg := range bot.mainSegment.CollectSegments(make([]*Segment, 0)) {
+				for _, sub := range seg.subSegments {
+					parents[sub.index] = seg.index
+				}
+			}
+			for k, answer := range test.answerParents {
+				if parents[k+1] != answer {
+					t.Errorf("Error! For input test dataset %d segment %d of bot %d is attached to segment %d, want %d", i, k+1, bot.id, parents[k+1], answer)
+				}
+			}
+		}
+	}
+}
+
+func TestVariableLengthGenomes(t *testing.T) {
+	type test struct {
+		length1, length2 int // number of segment genes of the two parents, all of them expressed
+	}
+
+	tests := []test{
+		{8, 8},
+		{5, 12},
+		{20, 3},
+	}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.mutation = MutationConfig{}
+		pond := InitializePond(config)
+		parents := pond.swimbots[:2]
+		for k, length := range []int{test.length1, test.length2} {
+			for len(parents[k].segGenes) < length {
+				parents[k].segGenes = DuplicateSegmentGene(parents[k].segGenes, len(parents[k].segGenes)-1)
+			}
+			for len(parents[k].segGenes) > length {
+				parents[k].segGenes = DeleteSegmentGene(parents[k].segGenes, 0)
+			}
+			parents[k].botGene.numSegments = length
+			parents[k].BuildSegments()
+		}
+		//check if every child expresses the genes it has, and copies the tail of the longer parent
+		for n := 0; n < 20; n++ {
+			child := pond.Mating(0, 1, pond.nextBotID, config)
+			length := len(child.segGenes)
+			if length != test.length1 && length != test.length2 || child.botGene.numSegments != length {
+				t.Errorf("Error! For input test dataset %d a child has %d segment genes and expresses %d, want %d or %d of them all", i, length, child.botGene.numSegments, test.length1, test.length2)
+			}
+			if numBuilt := len(child.mainSegment.CollectSegments(make([]*Segment, 0))); numBuilt != length {
+				t.Errorf("Error! For input test dataset %d a child with %d segment genes has %d segments", i, length, numBuilt)
+			}
+			longer := parents[0]
+			if test.length2 > test.length1 {
+				longer = parents[1]
+			}
+			if test.length1 != test.length2 && length == len(longer.segGenes) && child.segGenes[length-1][4] != longer.segGenes[length-1][4] {
+				t.Errorf("Error! For input test dataset %d the last segment gene of a child has the length %v, want %v from the longer parent", i, child.segGenes[length-1][4], longer.segGenes[length-1][4])
+			}
+			pond.swimbots = append(pond.swimbots, child)
+		}
+		//check if the copy of the pond keeps the length of every genome
+		newPond := CopyPond(pond)
+		for k, bot := range pond.swimbots {
+			if bot != nil && len(newPond.swimbots[k].segGenes) != len(bot.segGenes) {
+				t.Errorf("Error! For input test dataset %d the copy of bot %d has %d segment genes, want %d", i, bot.id, len(newPond.swimbots[k].segGenes), len(bot.segGenes))
+			}
+		}
+		//check if the analysis counts every length a genome can grow to
+		numSegments := GetNumSegmentsMap(pond)
+		if len(numSegments) != maxGenomeLength-minNumSegments+1 || numSegments[test.length1] == 0 || numSegments[test.length2] == 0 {
+			t.Errorf("Error! For input test dataset %d the segment counts are %v, want a key for every length from %d to %d", i, numSegments, minNumSegments, maxGenomeLength)
+		}
+	}
+}
+
+func TestDuplicationDeletion(t *testing.T) {
+	type test struct {
+		duplication, deletion float64
+		answerLength          int // length of the genome after many mutations
+	}
+
+	tests := []test{
+		{0, 0, initialGenomeLength},
+		{1, 0, maxGenomeLength},
+		{0, 1, minNumSegments},
+	}
+
+	for i, test := range tests {
+		pond := InitializePond(NewSimulationConfig())
+		var mutation MutationConfig
+		mutation.numSegments = 1
+		mutation.duplication = test.duplication
+		mutation.deletion = test.deletion
+		bot := pond.swimbots[0]
+		for n := 0; n < 100; n++ {
+			bot.segGenes = MutateGenome(&bot.botGene, bot.segGenes, mutation, pond.rng.genome)
+			//the bot never expresses more segments than it has genes
+			if bot.botGene.numSegments < minNumSegments || bot.botGene.numSegments > len(bot.segGenes) {
+				t.Errorf("Error! For input test dataset %d after %d mutations the bot expresses %d of its %d segment genes", i, n+1, bot.botGene.numSegments, len(bot.segGenes))
+				break
+			}
+		}
+		if len(bot.segGenes) != test.answerLength {
+			t.Errorf("Error! For input test dataset %d the genome has %d segment genes, want %d", i, len(bot.segGenes), test.answerLength)
+		}
+	}
+}
+
+func TestDiploid(t *testing.T) {
+	type test struct {
+		rule        string
+		answerRed   float64 // red of a bot with the alleles 255 and 0
+		answerShare float64 // share of the children of two such bots that show the red 255
+	}
+
+	tests := []test{
+		{dominant, 255, 0.75},
+		{recessive, 0, 0.25},
+		{codominant, 127.5, 0.25},
+	}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.diploid = true
+		config.dominance.color = test.rule
+		config.mutation = MutationConfig{}
+		pond := InitializePond(config)
+		// two parents that carry the red alleles 255 and 0 in the main segment
+		for _, bot := range pond.swimbots[:2] {
+			bot.haplotypes[0].segGenes[0][0] = 255
+			bot.haplotypes[1].segGenes[0][0] = 0
+			bot.botGene, bot.segGenes = Express(bot.haplotypes, config.dominance)
+		}
+		if red := pond.swimbots[0].segGenes[0][0]; red != test.answerRed {
+			t.Errorf("Error! For input test dataset %d a bot with the alleles 255 and 0 shows the red %v, want %v", i, red, test.answerRed)
+		}
+		//check if the children show the Mendelian ratio, 1 in 4 is homozygous for 255 and 1 in 2 heterozygous
+		numChildren := 2000
+		shown := 0
+		for n := 0; n < numChildren; n++ {
+			child := pond.Mating(0, 1, pond.nextBotID, config)
+			if len(child.haplotypes) != 2 || child.botGene.numSegments > len(child.segGenes) {
+				t.Errorf("Error! For input test dataset %d a child has %d haplotypes and expresses %d of its %d segment genes", i, len(child.haplotypes), child.botGene.numSegments, len(child.segGenes))
+				break
+			}
+			if child.segGenes[0][0] == 255 {
+				shown++
+			}
+		}
+		if share := float64(shown) / float64(numChildren); math.Abs(share-test.answerShare) > 0.04 {
+			t.Errorf("Error! For input test dataset %d a share of %v of the children show the red 255, want %v", i, share, test.answerShare)
+		}
+		//check if the copy of the pond doesn't share the haplotypes with the old pond
+		newPond := CopyPond(pond)
+		newPond.swimbots[0].haplotypes[0].segGenes[0][0] = 1
+		if pond.swimbots[0].haplotypes[0].segGenes[0][0] != 255 {
+			t.Errorf("Error! For input test dataset %d changing the copy of the pond changed the haplotypes of the old pond", i)
+		}
+	}
+}
+
+func TestBehaviourGenes(t *testing.T) {
+	type test struct {
+		behaviourGenes bool
+		viewRange      float64 // view range gene of a hungry bot 100 away from the only food bit
+		answerFound    bool
+	}
+
+	tests := []test{
+		{true, 10, false},
+		{true, 300, true},
+		{false, 10, true},
+	}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.behaviourGenes = test.behaviourGenes
+		config.mutation = MutationConfig{}
+		config.matingPreference = "faster"
+		pond := InitializePond(config)
+		//check if the initial genes lie around the global parameters
+		if test.behaviourGenes {
+			for _, bot := range pond.swimbots {
+				if bot.botGene.viewRange < 240 || bot.botGene.viewRange > 360 || bot.botGene.hungerThreshold < 40 || bot.botGene.hungerThreshold > 60 {
+					t.Errorf("Error! For input test dataset %d bot %d starts with the view range %v and the hunger threshold %v, want 240 to 360 and 40 to 60", i, bot.id, bot.botGene.viewRange, bot.botGene.hungerThreshold)
+				}
+			}
+		}
+		// the bot is hungry by its own gene and by the global threshold
+		bot := pond.swimbots[0]
+		bot.botGene.viewRange = test.viewRange
+		bot.botGene.hungerThreshold = 1000
+		bot.energy = 10
+		bot.goal.id = -1
+		pond.foodBits = nil
+		pond.foodSlots = nil
+		pond.DropFood(OrderedPair{bot.position.x + 100, bot.position.y}, pond.rng.food)
+		newPond := CopyPond(pond)
+		newPond.UpdateSwimbot(0, pond, 1, config)
+		//check if the bot only sees the food when its view range reaches it
+		if found := newPond.swimbots[0].goal.id != -1; found != test.answerFound {
+			t.Errorf("Error! For input test dataset %d the bot found the food = %v, want %v", i, found, test.answerFound)
+		}
+		//check if the children inherit the genes of a parent
+		child := pond.Mating(1, 2, pond.nextBotID, config)
+		parent1, parent2 := pond.swimbots[1].botGene, pond.swimbots[2].botGene
+		if child.botGene.viewRange != parent1.viewRange && child.botGene.viewRange != parent2.viewRange {
+			t.Errorf("Error! For input test dataset %d the child has the view range %v, want %v or %v", i, child.botGene.viewRange, parent1.viewRange, parent2.viewRange)
+		}
+		if child.botGene.preference != parent1.preference && child.botGene.preference != parent2.preference {
+			t.Errorf("Error! For input test dataset %d the child has the preference %d, want %d or %d", i, child.botGene.preference, parent1.preference, parent2.preference)
+		}
+	}
+}
+
+func TestBehaviourGenesBoundedPopulation(t *testing.T) {
+	type test struct {
+		hungerThreshold float64 // global threshold around which the initial genes lie
+		answerMaxBots   int
+	}
+
+	tests := []test{{5, 800}, {50, 800}}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.numGens = 250
+		config.numInitialBots = 40
+		config.width, config.height = 600, 600
+		config.hungerThreshold = test.hungerThreshold
+		config.behaviourGenes = true
+		config.behaviourSpread = 0.8
+		config.mutation.hungerThreshold.rate = 0.5
+		sim := NewSimulator(config)
+		//check if the hunger threshold gene can't evolve low enough for starving bots to breed without bound,
+		//stepping by hand so an exploding population stops the run instead of slowing it to a crawl
+		for sim.Generation() < config.numGens {
+			pond := sim.Step()
+			numBots := 0
+			for _, bot := range pond.swimbots {
+				if bot != nil {
+					numBots++
+				}
+			}
+			if numBots > test.answerMaxBots {
+				t.Errorf("Error! For input test dataset %d the population grew to %d bots in generation %d, want at most %d", i, numBots, sim.Generation(), test.answerMaxBots)
+				break
+			}
+		}
+	}
+}
+
+func TestNeuralSteering(t *testing.T) {
+	type test struct {
+		turnBias, throttleBias float64 // biases of the two output neurons, every other weight is 0
+		answerTurn             float64
+		answerThrottle         float64
+	}
+
+	tests := []test{
+		{0, 0, 0, 0.5},
+		{10, 0, 1, 0.5},
+		{-10, 10, -1, 1},
+		{0, -10, 0, 0},
+	}
+
+	for i, test := range tests {
+		config := NewSimulationConfig()
+		config.steering = neuralSteering
+		config.mutation = MutationConfig{}
+		pond := InitializePond(config)
+		for _, bot := range pond.swimbots {
+			if len(bot.brainGenes) != brainGenomeLength {
+				t.Errorf("Error! For input test dataset %d bot %d has %d brain genes, want %d", i, bot.id, len(bot.brainGenes), brainGenomeLength)
+			}
+		}
+		//check if the child gets every weight from one of its parents
+		child := pond.Mating(1, 2, pond.nextBotID, config)
+		for k, w := range child.brainGenes {
+			if w != pond.swimbots[1].brainGenes[k] && w != pond.swimbots[2].brainGenes[k] {
+				t.Errorf("Error! For input test dataset %d weight %d of the child is %v, want %v or %v", i, k, w, pond.swimbots[1].brainGenes[k], pond.swimbots[2].brainGenes[k])
+			}
+		}
+
+		weights := make([]float64, brainGenomeLength)
+		weights[(numBrainInputs+1)*numBrainHidden] = test.turnBias
+		weights[(numBrainInputs+1)*numBrainHidden+numBrainHidden+1] = test.throttleBias
+		turn, throttle := NeuralBrain{weights}.Steer(make([]float64, numBrainInputs))
+		if math.Abs(turn-test.answerTurn) > 1e-6 || math.Abs(throttle-test.answerThrottle) > 1e-6 {
+			t.Errorf("Error! For input test dataset %d the brain steers with the turn %v and the throttle %v, want %v and %v", i, turn, throttle, test.answerTurn, test.answerThrottle)
+		}
+		//check if the bot turns and swims as its brain says
+		bot := pond.swimbots[0]
+		bot.brainGenes = weights
+		heading := math.Atan2(bot.velocity.y, bot.velocity.x)
+		bot.UpdateVelocity(pond, config)
+		turned := math.Remainder(math.Atan2(bot.velocity.y, bot.velocity.x)-heading, 2*math.Pi)
+		speed := math.Hypot(bot.velocity.x, bot.velocity.y)
+		answerSpeed := math.Max(test.answerThrottle*bot.botGene.translationalMovement, minTranslationalMovement)
+		if answerTurned := test.answerTurn * bot.botGene.angularMovement; math.Abs(turned-answerTurned) > 1e-6 || math.Abs(speed-answerSpeed) > 1e-6 {
+			t.Errorf("Error! For input test dataset %d the bot turned by %v at the speed %v, want %v and %v", i, turned, speed, answerTurned, answerSpeed)
+		}
+	}
+}
+
 func ReadUpdateSegPosInputFromFile(directory string, inputFile os.FileInfo) (*Segment, []SegmentGene) {
 	fileName := inputFile.Name() //grab file name
 
@@ -294,7 +1626,7 @@ func SwimbotistheSame(bot1 *Swimbot, bot2 *Swimbot) bool {
 		return false
 	} else if bot1.age != bot2.age {
 		return false
-	} else if bot1.goal.isBot != bot2.goal.isBot || bot1.goal.index != bot2.goal.index {
+	} else if bot1.goal.isBot != bot2.goal.isBot || bot1.goal.id != bot2.goal.id {
 		return false
 	} else if !SliceIsTheSame(bot1.family, bot2.family) {
 		return false
@@ -312,7 +1644,7 @@ func ChildbotistheSame(bot1 *Swimbot, bot2 *Swimbot) bool {
 		return false
 	} else if bot1.age != bot2.age {
 		return false
-	} else if bot1.goal.isBot != bot2.goal.isBot || bot1.goal.index != bot2.goal.index {
+	} else if bot1.goal.isBot != bot2.goal.isBot || bot1.goal.id != bot2.goal.id {
 		return false
 	} else if !SliceIsTheSame(bot1.family, bot2.family) {
 		return false
@@ -362,7 +1694,7 @@ func ReadSwimbotFromFile(directory string, file os.FileInfo) *Swimbot {
 	} else {
 		bot.goal.isBot = true
 	}
-	bot.goal.index, err = strconv.Atoi(currentLine[1])
+	bot.goal.id, err = strconv.Atoi(currentLine[1])
 	if err != nil {
 		panic(err)
 	}
@@ -513,6 +1845,8 @@ func ReadPondFromFile(directory string, file os.FileInfo) *Pond {
 			if err != nil {
 				panic(err)
 			}
+			// the ponds in the test files are square
+			pond.height = pond.width
 			pond.swimbots = make([]*Swimbot, numBots)
 			pond.foodBits = make([]*Food, numFood)
 		}
@@ -536,7 +1870,7 @@ func ReadPondFromFile(directory string, file os.FileInfo) *Pond {
 				} else {
 					pond.swimbots[botIndex].goal.isBot = true
 				}
-				pond.swimbots[botIndex].goal.index, err = strconv.Atoi(currentLine[1])
+				pond.swimbots[botIndex].goal.id, err = strconv.Atoi(currentLine[1])
 				if err != nil {
 					panic(err)
 				}
@@ -637,6 +1971,15 @@ func ReadPondFromFile(directory string, file os.FileInfo) *Pond {
 	return p
 }
 
+// SkipWithoutFixtures skips the test if one of the directories holding its input and output files is missing
+func SkipWithoutFixtures(t *testing.T, directories ...string) {
+	for _, directory := range directories {
+		if _, err := os.Stat(directory); err != nil {
+			t.Skip("test files missing: " + directory)
+		}
+	}
+}
+
 func ReadFilesFromDirectory(directory string) []os.FileInfo {
 	dirContents, err := ioutil.ReadDir(directory)
 	if err != nil {